@@ -70,6 +70,13 @@ func (a ActionConfig) String() string {
 	return strings.Join(s, ", ")
 }
 
+// Plan is a weekly recurring charging target
+type Plan struct {
+	Days string `mapstructure:"days" json:"days"` // weekdays like `Mon-Fri`, empty means all days
+	Time string `mapstructure:"time" json:"time"` // time of day like `07:00`
+	SoC  int    `mapstructure:"soc" json:"soc"`   // target soc
+}
+
 // Meter is able to provide current power in W
 type Meter interface {
 	CurrentPower() (float64, error)
@@ -90,6 +97,27 @@ type Battery interface {
 	SoC() (float64, error)
 }
 
+// BatteryMode is the home battery operation mode
+type BatteryMode string
+
+// Battery modes
+const (
+	BatteryUnknown BatteryMode = ""
+	BatteryNormal  BatteryMode = "normal" // battery operates autonomously
+	BatteryHold    BatteryMode = "hold"   // battery must not discharge
+	BatteryCharge  BatteryMode = "charge" // battery charges from grid
+)
+
+// String implements Stringer
+func (m BatteryMode) String() string {
+	return string(m)
+}
+
+// BatteryController allows to control the home battery's operation mode
+type BatteryController interface {
+	SetBatteryMode(BatteryMode) error
+}
+
 // ChargeState provides current charging status
 type ChargeState interface {
 	Status() (ChargeStatus, error)
@@ -145,6 +173,7 @@ type Vehicle interface {
 	Capacity() int64
 	Identifiers() []string
 	OnIdentified() ActionConfig
+	Plans() []Plan
 }
 
 // VehicleFinishTimer provides estimated charge cycle finish time
@@ -192,6 +221,21 @@ type Tariff interface {
 	CurrentPrice() (float64, error) // EUR/kWh, CHF/kWh, ...
 }
 
+// Rate is a tariff price valid for the given time interval
+type Rate struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Price float64   `json:"price"`
+}
+
+// Rates is a time-ordered series of tariff prices
+type Rates []Rate
+
+// TariffForecast provides the tariff's future price series
+type TariffForecast interface {
+	Rates() (Rates, error)
+}
+
 type WebController interface {
 	WebControl(*mux.Router)
 }
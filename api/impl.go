@@ -24,6 +24,20 @@ func ChargeModeString(mode string) (ChargeMode, error) {
 	}
 }
 
+// BatteryModeString converts string to BatteryMode
+func BatteryModeString(mode string) (BatteryMode, error) {
+	switch strings.ToLower(mode) {
+	case string(BatteryNormal):
+		return BatteryNormal, nil
+	case string(BatteryHold):
+		return BatteryHold, nil
+	case string(BatteryCharge):
+		return BatteryCharge, nil
+	default:
+		return BatteryUnknown, fmt.Errorf("invalid value: %s", mode)
+	}
+}
+
 var _ encoding.TextUnmarshaler = (*ChargeMode)(nil)
 
 func (c *ChargeMode) UnmarshalText(text []byte) error {
@@ -3,18 +3,26 @@ package charger
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/dylanmei/iso8601"
 	"github.com/evcc-io/eebus/app"
 	"github.com/evcc-io/eebus/communication"
+	"github.com/evcc-io/eebus/device/feature"
 	"github.com/evcc-io/eebus/ship"
+	"github.com/evcc-io/eebus/spine"
+	"github.com/evcc-io/eebus/spine/model"
 	"github.com/evcc-io/evcc/api"
 	"github.com/evcc-io/evcc/core/loadpoint"
 	"github.com/evcc-io/evcc/server"
 	"github.com/evcc-io/evcc/util"
 )
 
-const maxIdRequestTimespan = time.Second * 120
+const (
+	maxIdRequestTimespan = time.Second * 120
+	planUpdateInterval   = time.Second * 10
+)
 
 type EEBus struct {
 	log           *util.Logger
@@ -22,6 +30,7 @@ type EEBus struct {
 	lp            loadpoint.API
 	forcePVLimits bool
 
+	mu                              sync.Mutex // guards communication standard and charging plan
 	communicationStandard           communication.EVCommunicationStandardEnumType
 	socSupportAvailable             bool
 	selfConsumptionSupportAvailable bool
@@ -31,6 +40,11 @@ type EEBus struct {
 	expectedEnableState bool
 
 	evConnectedTime time.Time
+
+	timeSeries  *eebusTimeSeries
+	planOnce    sync.Once
+	planFeature spine.Feature // ev time series the charging plan was written to
+	planTarget  time.Time     // target time of the written charging plan
 }
 
 func init() {
@@ -74,9 +88,15 @@ func (c *EEBus) onConnect(ski string, conn ship.Conn) error {
 	c.log.TRACE.Println("!! onCconnect invoked on ski ", ski)
 
 	eebusDevice := app.HEMS(server.EEBusInstance.DeviceInfo())
+	c.timeSeries = newEEBusTimeSeries(eebusDevice)
 	c.cc = communication.NewConnectionController(c.log.TRACE, conn, eebusDevice)
 	c.cc.SetDataUpdateHandler(c.dataUpdateHandler)
 
+	// the connection controller only registers as delegate for the original feature
+	if c.timeSeries != nil {
+		c.timeSeries.Delegate = c.cc
+	}
+
 	c.connected = true
 	c.setDefaultValues()
 
@@ -93,11 +113,17 @@ func (c *EEBus) onDisconnect(ski string) {
 }
 
 func (c *EEBus) setDefaultValues() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.expectedEnableState = false
 
 	c.communicationStandard = communication.EVCommunicationStandardEnumTypeUnknown
 	c.socSupportAvailable = false
 	c.selfConsumptionSupportAvailable = false
+
+	c.planFeature = nil
+	c.planTarget = time.Time{}
 }
 
 func (c *EEBus) setLoadpointMinMaxLimits(data *communication.EVSEClientDataType) {
@@ -125,12 +151,15 @@ func (c *EEBus) showCurrentChargingSetup() {
 		return
 	}
 
+	c.mu.Lock()
 	prevComStandard := c.communicationStandard
+	c.communicationStandard = data.EVData.CommunicationStandard
+	c.mu.Unlock()
+
 	prevSoCSupport := c.socSupportAvailable
 	prevSelfConsumptionSupport := c.selfConsumptionSupportAvailable
 
 	if prevComStandard != data.EVData.CommunicationStandard {
-		c.communicationStandard = data.EVData.CommunicationStandard
 		timestamp := time.Now()
 		c.log.WARN.Println("!! ", timestamp.Format("2006-01-02 15:04:05"), " ev-charger-communication changed from ", prevComStandard, " to ", data.EVData.CommunicationStandard)
 	}
@@ -166,11 +195,19 @@ func (c *EEBus) dataUpdateHandler(dataType communication.EVDataElementUpdateType
 	case communication.EVDataElementUpdateEVConnectionState:
 		if data.EVData.ChargeState == communication.EVChargeStateEnumTypeUnplugged {
 			c.expectedEnableState = false
+
+			c.mu.Lock()
+			c.planFeature = nil
+			c.mu.Unlock()
 		}
 		c.setLoadpointMinMaxLimits(data)
 	case communication.EVDataElementUpdateCommunicationStandard:
+		c.mu.Lock()
 		c.communicationStandard = data.EVData.CommunicationStandard
+		c.mu.Unlock()
+
 		c.setLoadpointMinMaxLimits(data)
+		c.updateChargingPlan()
 	case communication.EVDataElementUpdateAsymetricChargingType:
 		c.setLoadpointMinMaxLimits(data)
 	// case communication.EVDataElementUpdateEVSEOperationState:
@@ -527,6 +564,9 @@ var _ loadpoint.Controller = (*EEBus)(nil)
 func (c *EEBus) LoadpointControl(lp loadpoint.API) {
 	c.lp = lp
 
+	// forward target charge to the ev
+	c.planOnce.Do(func() { go c.planUpdater() })
+
 	// set current known min, max current limits
 	data, err := c.cc.GetData()
 	if err != nil {
@@ -535,3 +575,156 @@ func (c *EEBus) LoadpointControl(lp loadpoint.API) {
 	c.setLoadpointMinMaxLimits(data)
 	c.showCurrentChargingSetup()
 }
+
+// planUpdater periodically forwards the loadpoint target charge to the ev
+func (c *EEBus) planUpdater() {
+	for range time.NewTicker(planUpdateInterval).C {
+		c.updateChargingPlan()
+	}
+}
+
+// updateChargingPlan writes the loadpoint target charge as power plan to ISO 15118 vehicles
+func (c *EEBus) updateChargingPlan() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lp == nil || c.timeSeries == nil || !c.connected {
+		return
+	}
+
+	// IEC 61851 vehicles can't receive charging plans
+	if c.communicationStandard == communication.EVCommunicationStandardEnumTypeUnknown ||
+		c.communicationStandard == communication.EVCommunicationStandardEnumTypeIEC61851 {
+		return
+	}
+
+	rf := c.timeSeries.server()
+	now := time.Now()
+	target := eebusPlanTarget(c.lp.GetTargetTime(), now)
+
+	if rf == nil || rf == c.planFeature && target.Equal(c.planTarget) {
+		return
+	}
+
+	id, ok := c.timeSeries.constraintsID()
+	if !ok {
+		c.log.TRACE.Println("!! charging plan: ev does not accept power constraints")
+		return
+	}
+
+	data := eebusChargingPlan(id, now, target, c.lp.GetMinPower(), c.lp.GetMaxPower())
+
+	if err := c.timeSeries.writePlan(rf, data); err != nil {
+		c.log.ERROR.Println("failed to send charging plan: ", err)
+		return
+	}
+
+	c.log.DEBUG.Printf("charging plan: target %v", target.Round(time.Second))
+	c.planFeature, c.planTarget = rf, target
+}
+
+// eebusPlanTarget returns the target time of the charging plan. Targets not in the future
+// are dropped, which rewrites the plan once the written target has passed.
+func eebusPlanTarget(target, now time.Time) time.Time {
+	if !target.After(now) {
+		return time.Time{}
+	}
+	return target
+}
+
+// eebusChargingPlan creates the power constraints time series for the given target time.
+// Maximum power is allowed until the target, minimum power afterwards. Without target
+// maximum power is allowed at any time.
+func eebusChargingPlan(id uint, now, target time.Time, minPower, maxPower float64) []model.TimeSeriesDataType {
+	type slot struct {
+		duration time.Duration
+		power    float64
+	}
+
+	slots := []slot{{24 * time.Hour, maxPower}}
+	if remaining := target.Sub(now).Round(time.Second); !target.IsZero() && remaining > 0 {
+		slots = []slot{{remaining, maxPower}, {24 * time.Hour, minPower}}
+	}
+
+	seriesID := model.TimeSeriesIdType(id)
+	startTime := "PT0S"
+
+	data := model.TimeSeriesDataType{
+		TimeSeriesId: &seriesID,
+		TimePeriod:   &model.TimePeriodType{StartTime: &startTime},
+	}
+
+	for i, s := range slots {
+		slotID := model.TimeSeriesSlotIdType(i)
+		duration := iso8601.FormatDuration(s.duration)
+
+		data.TimeSeriesSlot = append(data.TimeSeriesSlot, model.TimeSeriesSlotType{
+			TimeSeriesSlotId: &slotID,
+			Duration:         &duration,
+			MaxValue:         model.NewScaledNumberType(s.power),
+		})
+	}
+
+	return []model.TimeSeriesDataType{data}
+}
+
+// eebusTimeSeries wraps the time series client to retain the ev server feature for writing
+type eebusTimeSeries struct {
+	*feature.TimeSeries
+	mu   sync.Mutex
+	ctrl spine.Context
+	rf   spine.Feature
+}
+
+// newEEBusTimeSeries replaces the time series client of the device's CEM entity
+func newEEBusTimeSeries(device spine.Device) *eebusTimeSeries {
+	entity := device.EntityByType(model.EntityTypeType(model.EntityTypeEnumTypeCEM))
+	if entity == nil {
+		return nil
+	}
+
+	features := entity.GetFeatures()
+	for i, f := range features {
+		if ts, ok := f.(*feature.TimeSeries); ok {
+			res := &eebusTimeSeries{TimeSeries: ts}
+			features[i] = res
+			return res
+		}
+	}
+
+	return nil
+}
+
+// ServerFound implements the spine.ClientFeature interface
+func (f *eebusTimeSeries) ServerFound(ctrl spine.Context, rf spine.Feature) error {
+	f.mu.Lock()
+	f.ctrl, f.rf = ctrl, rf
+	f.mu.Unlock()
+
+	return f.TimeSeries.ServerFound(ctrl, rf)
+}
+
+// server returns the ev time series server feature
+func (f *eebusTimeSeries) server() spine.Feature {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rf
+}
+
+// constraintsID returns the id of the writable power constraints time series
+func (f *eebusTimeSeries) constraintsID() (uint, bool) {
+	for _, item := range f.GetTimeSeriesDescriptionData() {
+		if item.TimeSeriesType == model.TimeSeriesTypeEnumTypeConstraints && item.IsSeriesWritable {
+			return item.TimeSeriesId, true
+		}
+	}
+
+	return 0, false
+}
+
+// writePlan writes the time series data to the ev server feature
+func (f *eebusTimeSeries) writePlan(rf spine.Feature, data []model.TimeSeriesDataType) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.WriteTimeSeriesPlanData(f.ctrl, rf, data)
+}
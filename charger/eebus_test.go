@@ -0,0 +1,39 @@
+package charger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEEBusChargingPlan(t *testing.T) {
+	now := time.Now()
+
+	tc := []struct {
+		title  string
+		target time.Time
+		slots  int
+	}{
+		{"no target", time.Time{}, 1},
+		{"future target", now.Add(time.Hour), 2},
+		{"passed target", now.Add(-time.Minute), 1},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		target := eebusPlanTarget(tc.target, now)
+		if !tc.target.After(now) && !target.IsZero() {
+			t.Errorf("expected target dropped, got %v", target)
+		}
+
+		data := eebusChargingPlan(1, now, target, 1380, 11040)
+		if len(data) != 1 || len(data[0].TimeSeriesSlot) != tc.slots {
+			t.Fatalf("expected %d slots, got %+v", tc.slots, data)
+		}
+
+		// max power until target
+		if power := data[0].TimeSeriesSlot[0].MaxValue.GetValue(); power != 11040 {
+			t.Errorf("expected max power, got %.0fW", power)
+		}
+	}
+}
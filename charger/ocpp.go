@@ -0,0 +1,208 @@
+package charger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/charger/ocpp"
+	"github.com/evcc-io/evcc/util"
+	"github.com/evcc-io/evcc/util/request"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// OCPP is an api.Charger implementation for OCPP 1.6J charge points connecting to the evcc central system
+type OCPP struct {
+	log     *util.Logger
+	cp      *ocpp.CP
+	timeout time.Duration
+	current float64
+	enabled bool
+}
+
+func init() {
+	registry.Add("ocpp", NewOCPPFromConfig)
+}
+
+// NewOCPPFromConfig creates an OCPP charger from generic config
+func NewOCPPFromConfig(other map[string]interface{}) (api.Charger, error) {
+	cc := struct {
+		StationID string
+		Connector int
+		Timeout   time.Duration
+	}{
+		Connector: 1,
+		Timeout:   request.Timeout,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	return NewOCPP(cc.StationID, cc.Connector, cc.Timeout)
+}
+
+// NewOCPP creates OCPP charger
+func NewOCPP(id string, connector int, timeout time.Duration) (*OCPP, error) {
+	log := util.NewLogger("ocpp")
+
+	if ocpp.Instance == nil {
+		ocpp.Instance = ocpp.New(log)
+	}
+
+	cp := ocpp.NewChargePoint(log, id, connector)
+	if err := ocpp.Instance.Register(id, cp); err != nil {
+		return nil, err
+	}
+
+	c := &OCPP{
+		log:     log,
+		cp:      cp,
+		timeout: timeout,
+	}
+
+	return c, nil
+}
+
+// Status implements the api.Charger interface
+func (c *OCPP) Status() (api.ChargeStatus, error) {
+	status, err := c.cp.Status()
+	if err != nil {
+		return api.StatusNone, err
+	}
+
+	if status.ErrorCode != core.NoError {
+		return api.StatusF, fmt.Errorf("%s: %s", status.ErrorCode, status.Info)
+	}
+
+	switch status.Status {
+	case core.ChargePointStatusAvailable, core.ChargePointStatusUnavailable, core.ChargePointStatusReserved:
+		return api.StatusA, nil
+	case core.ChargePointStatusPreparing, core.ChargePointStatusSuspendedEV, core.ChargePointStatusSuspendedEVSE, core.ChargePointStatusFinishing:
+		return api.StatusB, nil
+	case core.ChargePointStatusCharging:
+		return api.StatusC, nil
+	case core.ChargePointStatusFaulted:
+		return api.StatusF, fmt.Errorf("faulted: %s", status.Info)
+	default:
+		return api.StatusNone, fmt.Errorf("invalid status: %s", status.Status)
+	}
+}
+
+// Enabled implements the api.Charger interface
+func (c *OCPP) Enabled() (bool, error) {
+	return c.enabled, nil
+}
+
+// Enable implements the api.Charger interface
+func (c *OCPP) Enable(enable bool) error {
+	var current float64
+	if enable {
+		current = c.current
+	}
+
+	err := c.setChargingProfile(current)
+	if err == nil {
+		c.enabled = enable
+	}
+
+	return err
+}
+
+// MaxCurrent implements the api.Charger interface
+func (c *OCPP) MaxCurrent(current int64) error {
+	return c.MaxCurrentMillis(float64(current))
+}
+
+var _ api.ChargerEx = (*OCPP)(nil)
+
+// MaxCurrentMillis implements the api.ChargerEx interface
+func (c *OCPP) MaxCurrentMillis(current float64) error {
+	// don't re-enable charging by updating the limit
+	if !c.enabled {
+		c.current = current
+		return nil
+	}
+
+	err := c.setChargingProfile(current)
+	if err == nil {
+		c.current = current
+	}
+
+	return err
+}
+
+// setChargingProfile applies the current limit as default profile and waits for confirmation
+func (c *OCPP) setChargingProfile(current float64) error {
+	if !c.cp.Connected() {
+		return ocpp.ErrNotConnected
+	}
+
+	profile := &types.ChargingProfile{
+		ChargingProfileId:      1,
+		StackLevel:             0,
+		ChargingProfilePurpose: types.ChargingProfilePurposeTxDefaultProfile,
+		ChargingProfileKind:    types.ChargingProfileKindAbsolute,
+		ChargingSchedule:       types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, current)),
+	}
+
+	errC := make(chan error, 1)
+	err := ocpp.Instance.SetChargingProfile(c.cp.ID(), func(conf *smartcharging.SetChargingProfileConfirmation, err error) {
+		if err == nil && conf.Status != smartcharging.ChargingProfileStatusAccepted {
+			err = fmt.Errorf("set charging profile: %s", conf.Status)
+		}
+
+		errC <- err
+	}, c.cp.Connector(), profile)
+
+	if err == nil {
+		select {
+		case err = <-errC:
+		case <-time.After(c.timeout):
+			err = api.ErrTimeout
+		}
+	}
+
+	return err
+}
+
+var _ api.Meter = (*OCPP)(nil)
+
+// CurrentPower implements the api.Meter interface
+func (c *OCPP) CurrentPower() (float64, error) {
+	return c.cp.Measurement(types.MeasurandPowerActiveImport, "")
+}
+
+var _ api.MeterEnergy = (*OCPP)(nil)
+
+// TotalEnergy implements the api.MeterEnergy interface
+func (c *OCPP) TotalEnergy() (float64, error) {
+	return c.cp.Measurement(types.MeasurandEnergyActiveImportRegister, "")
+}
+
+var _ api.MeterCurrent = (*OCPP)(nil)
+
+// Currents implements the api.MeterCurrent interface
+func (c *OCPP) Currents() (float64, float64, float64, error) {
+	var currents []float64
+
+	for _, phase := range []types.Phase{types.PhaseL1, types.PhaseL2, types.PhaseL3} {
+		current, err := c.cp.Measurement(types.MeasurandCurrentImport, phase)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		currents = append(currents, current)
+	}
+
+	return currents[0], currents[1], currents[2], nil
+}
+
+var _ api.Identifier = (*OCPP)(nil)
+
+// Identify implements the api.Identifier interface
+func (c *OCPP) Identify() (string, error) {
+	return c.cp.IdTag(), nil
+}
@@ -0,0 +1,208 @@
+package ocpp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/util"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+// ErrNotConnected is returned while the charge point is not connected
+var ErrNotConnected = errors.New("not connected")
+
+// CP is the state of a single charge point connector
+type CP struct {
+	mu        sync.Mutex
+	log       *util.Logger
+	id        string
+	connector int
+
+	connected bool
+	status    *core.StatusNotificationRequest
+	txnID     int
+	idTag     string
+
+	measurements map[string]types.SampledValue
+}
+
+// NewChargePoint creates the charge point connector state
+func NewChargePoint(log *util.Logger, id string, connector int) *CP {
+	return &CP{
+		log:          log,
+		id:           id,
+		connector:    connector,
+		measurements: make(map[string]types.SampledValue),
+	}
+}
+
+// ID returns the station id
+func (cp *CP) ID() string {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.id
+}
+
+// Connector returns the connector id
+func (cp *CP) Connector() int {
+	return cp.connector
+}
+
+func (cp *CP) bind(id string) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.id = id
+}
+
+func (cp *CP) connect(connected bool) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.connected = connected
+}
+
+// Connected returns the connection state
+func (cp *CP) Connected() bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.connected
+}
+
+func (cp *CP) statusNotification(request *core.StatusNotificationRequest) {
+	if request.ConnectorId != cp.connector {
+		return
+	}
+
+	cp.log.TRACE.Printf("%s: status: %s (%s)", cp.ID(), request.Status, request.ErrorCode)
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.status = request
+}
+
+// Status returns the last status notification
+func (cp *CP) Status() (*core.StatusNotificationRequest, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if !cp.connected {
+		return nil, ErrNotConnected
+	}
+
+	if cp.status == nil {
+		return nil, errors.New("waiting for status")
+	}
+
+	return cp.status, nil
+}
+
+func (cp *CP) startTransaction(request *core.StartTransactionRequest, txnID int) {
+	if request.ConnectorId != cp.connector {
+		return
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.txnID = txnID
+	cp.idTag = request.IdTag
+}
+
+func (cp *CP) stopTransaction(request *core.StopTransactionRequest) {
+	cp.mu.Lock()
+	if request.TransactionId != cp.txnID {
+		cp.mu.Unlock()
+		return
+	}
+
+	cp.txnID = 0
+	cp.idTag = ""
+	cp.mu.Unlock()
+
+	cp.meterValues(cp.connector, request.TransactionData)
+}
+
+// TransactionID returns the current transaction id or 0 if no transaction is active
+func (cp *CP) TransactionID() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.txnID
+}
+
+// IdTag returns the id tag of the current transaction
+func (cp *CP) IdTag() string {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	return cp.idTag
+}
+
+// measurandKey returns the measurement key for measurand and phase
+func measurandKey(measurand types.Measurand, phase types.Phase) string {
+	if measurand == "" {
+		measurand = types.MeasurandEnergyActiveImportRegister
+	}
+
+	if phase == "" {
+		return string(measurand)
+	}
+
+	// treat L1-N like L1
+	return string(measurand) + "." + strings.TrimSuffix(string(phase), "-N")
+}
+
+func (cp *CP) meterValues(connector int, values []types.MeterValue) {
+	if connector != cp.connector {
+		return
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	for _, mv := range values {
+		for _, sv := range mv.SampledValue {
+			cp.measurements[measurandKey(sv.Measurand, sv.Phase)] = sv
+		}
+	}
+}
+
+// Measurement returns the measurement in base units W, kWh and A
+func (cp *CP) Measurement(measurand types.Measurand, phase types.Phase) (float64, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if !cp.connected {
+		return 0, ErrNotConnected
+	}
+
+	sv, ok := cp.measurements[measurandKey(measurand, phase)]
+	if !ok {
+		return 0, api.ErrNotAvailable
+	}
+
+	f, err := strconv.ParseFloat(sv.Value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch sv.Unit {
+	case types.UnitOfMeasureKW:
+		f *= 1e3
+	case types.UnitOfMeasureWh, "":
+		// energy defaults to Wh
+		if strings.HasPrefix(measurandKey(measurand, ""), "Energy") {
+			f /= 1e3
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,232 @@
+package ocpp
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/evcc-io/evcc/util"
+	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+const (
+	// HeartbeatInterval is the heartbeat interval requested from charge points
+	HeartbeatInterval = time.Minute
+
+	// MeterValueSampleInterval is the meter value interval requested from charge points
+	MeterValueSampleInterval = 10 * time.Second
+
+	// measurands requested from charge points
+	meterValuesSampledData = "Power.Active.Import,Energy.Active.Import.Register,Current.Import"
+)
+
+// Instance is the OCPP central system instance
+// This is needed since all charge points connect to the same websocket endpoint
+var Instance *CS
+
+// CS is the OCPP central system
+type CS struct {
+	ocpp16.CentralSystem
+	mu     sync.Mutex
+	log    *util.Logger
+	server *server
+	txnID  int
+	cps    map[string]*CP
+}
+
+// New creates the central system. Connections are accepted by ServeHTTP.
+func New(log *util.Logger) *CS {
+	server := newServer()
+
+	cs := &CS{
+		CentralSystem: ocpp16.NewCentralSystem(nil, server),
+		log:           log,
+		server:        server,
+		cps:           make(map[string]*CP),
+	}
+
+	cs.SetCoreHandler(cs)
+	cs.SetNewChargePointHandler(cs.connect)
+	cs.SetChargePointDisconnectedHandler(cs.disconnect)
+
+	go cs.errorHandler(server.Errors())
+	go cs.errorHandler(cs.Errors())
+
+	// starts the dispatcher, the websocket server itself is a no-op
+	cs.Start(0, "")
+
+	return cs
+}
+
+// errorHandler logs error channel
+func (cs *CS) errorHandler(errC <-chan error) {
+	for err := range errC {
+		cs.log.ERROR.Println(err)
+	}
+}
+
+// ServeHTTP accepts charge point websocket connections
+func (cs *CS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cs.server.ServeHTTP(w, r)
+}
+
+// Register adds a charge point. An empty id binds the charge point to the first unknown station connecting.
+func (cs *CS) Register(id string, cp *CP) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if _, ok := cs.cps[id]; ok {
+		if id == "" {
+			return fmt.Errorf("duplicate charge point without station id")
+		}
+		return fmt.Errorf("duplicate charge point: %s", id)
+	}
+
+	cs.cps[id] = cp
+
+	return nil
+}
+
+// chargePoint returns the registered charge point for the station id
+func (cs *CS) chargePoint(id string) (*CP, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cp, ok := cs.cps[id]; ok {
+		return cp, nil
+	}
+
+	// bind unknown station to charge point without id
+	if cp, ok := cs.cps[""]; ok {
+		cs.log.INFO.Printf("binding station %s to charge point without station id", id)
+
+		delete(cs.cps, "")
+		cs.cps[id] = cp
+		cp.bind(id)
+
+		return cp, nil
+	}
+
+	return nil, fmt.Errorf("unknown charge point: %s", id)
+}
+
+func (cs *CS) connect(chargePoint ocpp16.ChargePointConnection) {
+	id := chargePoint.ID()
+	cs.log.DEBUG.Printf("charge point connected: %s", id)
+
+	cp, err := cs.chargePoint(id)
+	if err != nil {
+		cs.log.WARN.Println(err)
+		return
+	}
+
+	cp.connect(true)
+
+	// request required measurands, not all charge points support changing the configuration
+	go cs.configure(id)
+}
+
+func (cs *CS) disconnect(chargePoint ocpp16.ChargePointConnection) {
+	id := chargePoint.ID()
+	cs.log.DEBUG.Printf("charge point disconnected: %s", id)
+
+	if cp, err := cs.chargePoint(id); err == nil {
+		cp.connect(false)
+	}
+}
+
+// configure requests meter values from the charge point
+func (cs *CS) configure(id string) {
+	for key, val := range map[string]string{
+		"MeterValuesSampledData":   meterValuesSampledData,
+		"MeterValueSampleInterval": fmt.Sprintf("%d", int(MeterValueSampleInterval.Seconds())),
+	} {
+		key := key
+		if err := cs.ChangeConfiguration(id, func(conf *core.ChangeConfigurationConfirmation, err error) {
+			if err == nil && conf.Status != core.ConfigurationStatusAccepted {
+				err = fmt.Errorf("%s", conf.Status)
+			}
+			if err != nil {
+				cs.log.DEBUG.Printf("%s: change configuration %s: %v", id, key, err)
+			}
+		}, key, val); err != nil {
+			cs.log.DEBUG.Printf("%s: change configuration %s: %v", id, key, err)
+		}
+	}
+}
+
+// OnAuthorize accepts any id tag, authorization is left to the charge point
+func (cs *CS) OnAuthorize(id string, request *core.AuthorizeRequest) (*core.AuthorizeConfirmation, error) {
+	cs.log.TRACE.Printf("%s: authorize: %s", id, request.IdTag)
+
+	return core.NewAuthorizationConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted)), nil
+}
+
+// OnBootNotification accepts the charge point
+func (cs *CS) OnBootNotification(id string, request *core.BootNotificationRequest) (*core.BootNotificationConfirmation, error) {
+	cs.log.DEBUG.Printf("%s: boot: %s %s", id, request.ChargePointVendor, request.ChargePointModel)
+
+	return core.NewBootNotificationConfirmation(types.NewDateTime(time.Now()), int(HeartbeatInterval.Seconds()), core.RegistrationStatusAccepted), nil
+}
+
+// OnDataTransfer accepts vendor specific data without processing it
+func (cs *CS) OnDataTransfer(id string, request *core.DataTransferRequest) (*core.DataTransferConfirmation, error) {
+	cs.log.TRACE.Printf("%s: data transfer: %s", id, request.VendorId)
+
+	return core.NewDataTransferConfirmation(core.DataTransferStatusAccepted), nil
+}
+
+// OnHeartbeat returns the current time
+func (cs *CS) OnHeartbeat(id string, request *core.HeartbeatRequest) (*core.HeartbeatConfirmation, error) {
+	return core.NewHeartbeatConfirmation(types.NewDateTime(time.Now())), nil
+}
+
+// OnMeterValues updates the charge point measurements
+func (cs *CS) OnMeterValues(id string, request *core.MeterValuesRequest) (*core.MeterValuesConfirmation, error) {
+	cp, err := cs.chargePoint(id)
+	if err == nil {
+		cp.meterValues(request.ConnectorId, request.MeterValue)
+	}
+
+	return core.NewMeterValuesConfirmation(), err
+}
+
+// OnStatusNotification updates the charge point status
+func (cs *CS) OnStatusNotification(id string, request *core.StatusNotificationRequest) (*core.StatusNotificationConfirmation, error) {
+	cp, err := cs.chargePoint(id)
+	if err == nil {
+		cp.statusNotification(request)
+	}
+
+	return core.NewStatusNotificationConfirmation(), err
+}
+
+// OnStartTransaction assigns a transaction id and accepts the transaction
+func (cs *CS) OnStartTransaction(id string, request *core.StartTransactionRequest) (*core.StartTransactionConfirmation, error) {
+	cp, err := cs.chargePoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	cs.txnID++
+	txnID := cs.txnID
+	cs.mu.Unlock()
+
+	cp.startTransaction(request, txnID)
+
+	return core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), txnID), nil
+}
+
+// OnStopTransaction finishes the transaction
+func (cs *CS) OnStopTransaction(id string, request *core.StopTransactionRequest) (*core.StopTransactionConfirmation, error) {
+	cp, err := cs.chargePoint(id)
+	if err == nil {
+		cp.stopTransaction(request)
+	}
+
+	return core.NewStopTransactionConfirmation(), err
+}
@@ -0,0 +1,133 @@
+package ocpp
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/util"
+	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ws"
+)
+
+// smartCharging records the charging profiles received by the test charge point
+type smartCharging struct {
+	limit chan float64
+}
+
+func (h *smartCharging) OnSetChargingProfile(request *smartcharging.SetChargingProfileRequest) (*smartcharging.SetChargingProfileConfirmation, error) {
+	h.limit <- request.ChargingProfile.ChargingSchedule.ChargingSchedulePeriod[0].Limit
+	return smartcharging.NewSetChargingProfileConfirmation(smartcharging.ChargingProfileStatusAccepted), nil
+}
+
+func (h *smartCharging) OnClearChargingProfile(request *smartcharging.ClearChargingProfileRequest) (*smartcharging.ClearChargingProfileConfirmation, error) {
+	return smartcharging.NewClearChargingProfileConfirmation(smartcharging.ClearChargingProfileStatusAccepted), nil
+}
+
+func (h *smartCharging) OnGetCompositeSchedule(request *smartcharging.GetCompositeScheduleRequest) (*smartcharging.GetCompositeScheduleConfirmation, error) {
+	return smartcharging.NewGetCompositeScheduleConfirmation(smartcharging.GetCompositeScheduleStatusRejected), nil
+}
+
+func TestCentralSystem(t *testing.T) {
+	log := util.NewLogger("foo")
+
+	cs := New(log)
+	cp := NewChargePoint(log, "", 1)
+	if err := cs.Register("", cp); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	client := ocpp16.NewChargePoint("station", nil, ws.NewClient())
+	sc := &smartCharging{limit: make(chan float64, 1)}
+	client.SetSmartChargingHandler(sc)
+
+	if err := client.Start(strings.Replace(srv.URL, "http", "ws", 1) + "/ocpp"); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Stop()
+
+	if _, err := client.BootNotification("model", "vendor"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cp.Connected() || cp.ID() != "station" {
+		t.Fatalf("charge point not bound: %s", cp.ID())
+	}
+
+	if _, err := client.StatusNotification(1, core.NoError, core.ChargePointStatusCharging); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, err := cp.Status(); err != nil || status.Status != core.ChargePointStatusCharging {
+		t.Errorf("unexpected status: %v %v", status, err)
+	}
+
+	if _, err := client.StartTransaction(1, "tag", 0, types.NewDateTime(time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	if tag := cp.IdTag(); tag != "tag" {
+		t.Errorf("unexpected id tag: %s", tag)
+	}
+
+	if _, err := client.MeterValues(1, []types.MeterValue{{
+		Timestamp: types.NewDateTime(time.Now()),
+		SampledValue: []types.SampledValue{
+			{Value: "2.5", Measurand: types.MeasurandPowerActiveImport, Unit: types.UnitOfMeasureKW},
+			{Value: "1500", Measurand: types.MeasurandEnergyActiveImportRegister, Unit: types.UnitOfMeasureWh},
+			{Value: "10", Measurand: types.MeasurandCurrentImport, Phase: types.PhaseL2N, Unit: types.UnitOfMeasureA},
+		},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		measurand types.Measurand
+		phase     types.Phase
+		expected  float64
+	}{
+		{types.MeasurandPowerActiveImport, "", 2500},
+		{types.MeasurandEnergyActiveImportRegister, "", 1.5},
+		{types.MeasurandCurrentImport, types.PhaseL2, 10},
+	} {
+		if f, err := cp.Measurement(tc.measurand, tc.phase); err != nil || f != tc.expected {
+			t.Errorf("%s: expected %.1f, got %.1f %v", tc.measurand, tc.expected, f, err)
+		}
+	}
+
+	errC := make(chan error, 1)
+	profile := &types.ChargingProfile{
+		ChargingProfilePurpose: types.ChargingProfilePurposeTxDefaultProfile,
+		ChargingProfileKind:    types.ChargingProfileKindAbsolute,
+		ChargingSchedule:       types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 16)),
+	}
+
+	if err := cs.SetChargingProfile(cp.ID(), func(conf *smartcharging.SetChargingProfileConfirmation, err error) {
+		errC <- err
+	}, 1, profile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+
+	if limit := <-sc.limit; limit != 16 {
+		t.Errorf("unexpected limit: %.1f", limit)
+	}
+
+	if _, err := client.StopTransaction(1500, types.NewDateTime(time.Now()), cp.TransactionID()); err != nil {
+		t.Fatal(err)
+	}
+
+	if tag := cp.IdTag(); tag != "" {
+		t.Errorf("unexpected id tag after stop: %s", tag)
+	}
+}
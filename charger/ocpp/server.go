@@ -0,0 +1,272 @@
+package ocpp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ws"
+)
+
+// server implements the ocpp-go websocket server on top of an existing http router instead of starting its own listener
+type server struct {
+	mu                  sync.Mutex
+	upgrader            websocket.Upgrader
+	timeoutConfig       ws.ServerTimeoutConfig
+	conns               map[string]*conn
+	messageHandler      func(ws ws.Channel, data []byte) error
+	newClientHandler    func(ws ws.Channel)
+	disconnectedHandler func(ws ws.Channel)
+	errC                chan error
+}
+
+var _ ws.WsServer = (*server)(nil)
+
+// conn is a single charge point connection
+type conn struct {
+	id     string
+	ws     *websocket.Conn
+	tls    *tls.ConnectionState
+	out    chan []byte
+	closeC chan websocket.CloseError
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (c *conn) ID() string {
+	return c.id
+}
+
+func (c *conn) TLSConnectionState() *tls.ConnectionState {
+	return c.tls
+}
+
+func newServer() *server {
+	return &server{
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{types.V16Subprotocol},
+			// charge points don't send browser origins
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		timeoutConfig: ws.NewServerTimeoutConfig(),
+		conns:         make(map[string]*conn),
+	}
+}
+
+// Start is a no-op as connections are accepted by ServeHTTP
+func (s *server) Start(port int, listenPath string) {}
+
+// Stop closes all connections
+func (s *server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.closeC <- websocket.CloseError{Code: websocket.CloseGoingAway, Text: "shutdown"}
+	}
+}
+
+func (s *server) StopConnection(id string, closeError websocket.CloseError) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.conns[id]
+	if !ok {
+		return fmt.Errorf("unknown charge point: %s", id)
+	}
+
+	c.closeC <- closeError
+	return nil
+}
+
+func (s *server) Errors() <-chan error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.errC == nil {
+		s.errC = make(chan error, 1)
+	}
+	return s.errC
+}
+
+func (s *server) error(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.errC != nil {
+		select {
+		case s.errC <- err:
+		default:
+		}
+	}
+}
+
+func (s *server) SetMessageHandler(handler func(ws ws.Channel, data []byte) error) {
+	s.messageHandler = handler
+}
+
+func (s *server) SetNewClientHandler(handler func(ws ws.Channel)) {
+	s.newClientHandler = handler
+}
+
+func (s *server) SetDisconnectedClientHandler(handler func(ws ws.Channel)) {
+	s.disconnectedHandler = handler
+}
+
+func (s *server) SetTimeoutConfig(config ws.ServerTimeoutConfig) {
+	s.timeoutConfig = config
+}
+
+func (s *server) Write(id string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.conns[id]
+	if !ok {
+		return fmt.Errorf("unknown charge point: %s", id)
+	}
+
+	select {
+	case c.out <- data:
+		return nil
+	case <-c.done:
+		return errors.New("connection closed")
+	}
+}
+
+func (s *server) AddSupportedSubprotocol(subProto string) {
+	for _, sp := range s.upgrader.Subprotocols {
+		if sp == subProto {
+			return
+		}
+	}
+
+	s.upgrader.Subprotocols = append(s.upgrader.Subprotocols, subProto)
+}
+
+// SetBasicAuthHandler is not supported
+func (s *server) SetBasicAuthHandler(handler func(username string, password string) bool) {}
+
+func (s *server) SetCheckOriginHandler(handler func(r *http.Request) bool) {
+	s.upgrader.CheckOrigin = handler
+}
+
+// Addr is not applicable as the server is attached to the web server
+func (s *server) Addr() *net.TCPAddr {
+	return nil
+}
+
+// ServeHTTP accepts charge point connections. The charge point id is the final path element.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.error(fmt.Errorf("upgrade failed: %w", err))
+		return
+	}
+
+	c := &conn{
+		id:     path.Base(r.URL.Path),
+		ws:     ws,
+		tls:    r.TLS,
+		out:    make(chan []byte, 10),
+		closeC: make(chan websocket.CloseError, 1),
+		done:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	prev, exists := s.conns[c.id]
+	s.conns[c.id] = c
+	s.mu.Unlock()
+
+	// replace stale connection after charge point reconnected
+	if exists {
+		prev.close()
+	}
+
+	if s.newClientHandler != nil {
+		s.newClientHandler(c)
+	}
+
+	go s.writePump(c)
+	go s.readPump(c)
+}
+
+func (c *conn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.ws.Close()
+	})
+}
+
+// cleanup removes the connection and notifies the disconnect handler
+func (s *server) cleanup(c *conn) {
+	c.close()
+
+	s.mu.Lock()
+	current := s.conns[c.id] == c
+	if current {
+		delete(s.conns, c.id)
+	}
+	s.mu.Unlock()
+
+	if current && s.disconnectedHandler != nil {
+		s.disconnectedHandler(c)
+	}
+}
+
+func (s *server) readPump(c *conn) {
+	defer s.cleanup(c)
+
+	_ = c.ws.SetReadDeadline(time.Now().Add(s.timeoutConfig.PingWait))
+	c.ws.SetPingHandler(func(data string) error {
+		_ = c.ws.SetReadDeadline(time.Now().Add(s.timeoutConfig.PingWait))
+		return c.ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(s.timeoutConfig.WriteWait))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNormalClosure) {
+				s.error(fmt.Errorf("read failed for %s: %w", c.id, err))
+			}
+			return
+		}
+
+		_ = c.ws.SetReadDeadline(time.Now().Add(s.timeoutConfig.PingWait))
+
+		if s.messageHandler != nil {
+			if err := s.messageHandler(c, data); err != nil {
+				s.error(fmt.Errorf("handling failed for %s: %w", c.id, err))
+			}
+		}
+	}
+}
+
+func (s *server) writePump(c *conn) {
+	defer s.cleanup(c)
+
+	for {
+		select {
+		case data := <-c.out:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(s.timeoutConfig.WriteWait))
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				s.error(fmt.Errorf("write failed for %s: %w", c.id, err))
+				return
+			}
+
+		case ce := <-c.closeC:
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(ce.Code, ce.Text), time.Now().Add(s.timeoutConfig.WriteWait))
+			return
+
+		case <-c.done:
+			return
+		}
+	}
+}
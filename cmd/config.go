@@ -26,6 +26,9 @@ type config struct {
 	Profile      bool
 	Levels       map[string]string
 	Interval     time.Duration
+	Database     string
+	Auth         server.AuthConfig
+	TLS          server.TLSConfig
 	Mqtt         mqttConfig
 	Javascript   map[string]interface{}
 	Influx       server.InfluxConfig
@@ -41,8 +44,9 @@ type config struct {
 }
 
 type mqttConfig struct {
-	mqtt.Config `mapstructure:",squash"`
-	Topic       string
+	mqtt.Config   `mapstructure:",squash"`
+	Topic         string
+	HomeAssistant string // home assistant discovery prefix
 }
 
 func (conf *mqttConfig) RootTopic() string {
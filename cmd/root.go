@@ -6,9 +6,11 @@ import (
 	_ "net/http/pprof" // pprof handler
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/evcc-io/evcc/charger/ocpp"
 	"github.com/evcc-io/evcc/server"
 	"github.com/evcc-io/evcc/server/updater"
 	"github.com/evcc-io/evcc/util"
@@ -166,6 +168,11 @@ func run(cmd *cobra.Command, args []string) {
 		log.FATAL.Fatal(err)
 	}
 
+	// grid operator power limit
+	if server.EEBusInstance != nil && server.EEBusInstance.LPC != nil {
+		site.SetPowerLimiter(server.EEBusInstance.LPC)
+	}
+
 	// start broadcasting values
 	tee := &util.Tee{}
 
@@ -178,27 +185,43 @@ func run(cmd *cobra.Command, args []string) {
 		configureDatabase(conf.Influx, site.LoadPoints(), tee.Attach())
 	}
 
+	// setup prometheus metrics
+	if viper.GetBool("metrics") {
+		go server.NewPrometheus(site.LoadPoints()).Run(tee.Attach())
+	}
+
 	// setup mqtt publisher
 	if conf.Mqtt.Broker != "" {
-		publisher := server.NewMQTT(conf.Mqtt.RootTopic())
+		publisher := server.NewMQTT(conf.Mqtt.RootTopic(), conf.Mqtt.HomeAssistant)
 		go publisher.Run(site, pipe.NewDropper(ignoreMqtt...).Pipe(tee.Attach()))
 	}
 
 	// create webserver
+	auth, err := server.NewAuth(conf.Auth)
+	if err != nil {
+		log.FATAL.Fatal(err)
+	}
+
 	socketHub := server.NewSocketHub()
-	httpd := server.NewHTTPd(uri, site, socketHub, cache)
+	httpd := server.NewHTTPd(uri, site, socketHub, cache, auth)
 
 	// allow web access for vehicles
 	cp.webControl(httpd)
 
-	// metrics
+	// ocpp central system, charge points connect to /ocpp/<station id>
+	// charge points don't support evcc authentication, the endpoint remains open if auth is enabled
+	if ocpp.Instance != nil {
+		httpd.Router().PathPrefix("/ocpp/").Handler(ocpp.Instance)
+	}
+
+	// metrics, scraping requires a read token if auth is enabled
 	if viper.GetBool("metrics") {
-		httpd.Router().Handle("/metrics", promhttp.Handler())
+		httpd.Router().Handle("/metrics", auth.Handler(promhttp.Handler()))
 	}
 
 	// pprof
 	if viper.GetBool("profile") {
-		httpd.Router().PathPrefix("/debug/").Handler(http.DefaultServeMux)
+		httpd.Router().PathPrefix("/debug/").Handler(auth.Handler(http.DefaultServeMux))
 	}
 
 	// start HEMS server
@@ -259,5 +282,16 @@ func run(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}()
 
+	// serve https
+	if conf.TLS.Enabled() {
+		certFile, keyFile, err := conf.TLS.Files(filepath.Dir(cfgFile))
+		if err != nil {
+			log.FATAL.Fatal(err)
+		}
+
+		log.FATAL.Println(httpd.ListenAndServeTLS(certFile, keyFile))
+		return
+	}
+
 	log.FATAL.Println(httpd.ListenAndServe())
 }
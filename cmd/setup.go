@@ -13,11 +13,13 @@ import (
 	"github.com/evcc-io/evcc/api/proto/pb"
 	"github.com/evcc-io/evcc/core"
 	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/store"
 	"github.com/evcc-io/evcc/hems"
 	"github.com/evcc-io/evcc/provider/javascript"
 	"github.com/evcc-io/evcc/provider/mqtt"
 	"github.com/evcc-io/evcc/push"
 	"github.com/evcc-io/evcc/server"
+	"github.com/evcc-io/evcc/server/db"
 	"github.com/evcc-io/evcc/tariff"
 	"github.com/evcc-io/evcc/util"
 	"github.com/evcc-io/evcc/util/cloud"
@@ -54,6 +56,11 @@ func configureEnvironment(conf config) (err error) {
 		err = configureSponsorship(conf.SponsorToken)
 	}
 
+	// setup persistence
+	if err == nil && conf.Database != "" {
+		err = configureDB(conf.Database)
+	}
+
 	// setup mqtt client listener
 	if err == nil && conf.Mqtt.Broker != "" {
 		err = configureMQTT(conf.Mqtt)
@@ -105,14 +112,7 @@ func configureSponsorship(token string) error {
 
 // setup influx database
 func configureDatabase(conf server.InfluxConfig, loadPoints []loadpoint.API, in <-chan util.Param) {
-	influx := server.NewInfluxClient(
-		conf.URL,
-		conf.Token,
-		conf.Org,
-		conf.User,
-		conf.Password,
-		conf.Database,
-	)
+	influx := server.NewInfluxClient(conf)
 
 	// eliminate duplicate values
 	dedupe := pipe.NewDeduplicator(30*time.Minute, "vehicleCapacity", "vehicleSoC", "vehicleRange", "vehicleOdometer", "chargedEnergy", "chargeRemainingEnergy")
@@ -126,6 +126,16 @@ func configureDatabase(conf server.InfluxConfig, loadPoints []loadpoint.API, in
 	go influx.Run(loadPoints, in)
 }
 
+// setup persistence
+func configureDB(path string) error {
+	var err error
+	if db.Instance, err = db.New(path); err != nil {
+		return fmt.Errorf("failed configuring database: %w", err)
+	}
+
+	return nil
+}
+
 // setup mqtt
 func configureMQTT(conf mqttConfig) error {
 	log := util.NewLogger("mqtt")
@@ -233,7 +243,13 @@ func configureSiteAndLoadpoints(conf config) (site *core.Site, err error) {
 }
 
 func configureSite(conf map[string]interface{}, cp *ConfigProvider, loadPoints []*core.LoadPoint, tariffs tariff.Tariffs) (*core.Site, error) {
-	site, err := core.NewSiteFromConfig(log, cp, conf, loadPoints, tariffs)
+	// optional persistence
+	var persistence store.Store
+	if db.Instance != nil {
+		persistence = db.Instance
+	}
+
+	site, err := core.NewSiteFromConfig(log, cp, conf, loadPoints, tariffs, persistence)
 	if err != nil {
 		return nil, fmt.Errorf("failed configuring site: %w", err)
 	}
@@ -24,6 +24,24 @@ type dynamicType struct {
 
 type typeStruct struct {
 	Type, ShortType, Signature, Function, VarName string
+	Params, Args, ReturnTypes                     string
+}
+
+// splitSignature splits a function signature into named parameters, argument names and return types
+func splitSignature(signature string) (string, string, string) {
+	sig := strings.TrimPrefix(signature, "func(")
+	idx := strings.Index(sig, ")")
+
+	var params, args []string
+	if in := strings.TrimSpace(sig[:idx]); in != "" {
+		for i, typ := range strings.Split(in, ",") {
+			arg := fmt.Sprintf("p%d", i)
+			params = append(params, arg+" "+strings.TrimSpace(typ))
+			args = append(args, arg)
+		}
+	}
+
+	return strings.Join(params, ", "), strings.Join(args, ", "), strings.TrimSpace(sig[idx+1:])
 }
 
 func generate(out io.Writer, packageName, functionName, baseType string, dynamicTypes ...dynamicType) error {
@@ -72,13 +90,17 @@ func generate(out io.Writer, packageName, functionName, baseType string, dynamic
 
 	for _, dt := range dynamicTypes {
 		parts := strings.SplitN(dt.typ, ".", 2)
+		params, args, returnTypes := splitSignature(dt.signature)
 
 		types[dt.typ] = typeStruct{
-			Type:      dt.typ,
-			ShortType: parts[1],
-			VarName:   strings.ToLower(parts[1][:1]) + parts[1][1:],
-			Signature: dt.signature,
-			Function:  dt.function,
+			Type:        dt.typ,
+			ShortType:   parts[1],
+			VarName:     strings.ToLower(parts[1][:1]) + parts[1][1:],
+			Signature:   dt.signature,
+			Function:    dt.function,
+			Params:      params,
+			Args:        args,
+			ReturnTypes: returnTypes,
 		}
 
 		combos = append(combos, dt.typ)
@@ -4,12 +4,15 @@ import (
 	"github.com/avast/retry-go/v3"
 )
 
+// retryAttempts is the default number of attempts for retryable operations
+const retryAttempts = 3
+
 var (
 	status   = map[bool]string{false: "disable", true: "enable"}
 	presence = map[bool]string{false: "✗", true: "✓"}
 
 	// retryOptions ist the default options set for retryable operations
-	retryOptions = []retry.Option{retry.Attempts(3), retry.LastErrorOnly(true)}
+	retryOptions = []retry.Option{retry.Attempts(retryAttempts), retry.LastErrorOnly(true)}
 
 	// Voltage global value
 	Voltage float64
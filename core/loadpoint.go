@@ -11,7 +11,9 @@ import (
 
 	"github.com/evcc-io/evcc/api"
 	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/plan"
 	"github.com/evcc-io/evcc/core/soc"
+	"github.com/evcc-io/evcc/core/store"
 	"github.com/evcc-io/evcc/core/wrapper"
 	"github.com/evcc-io/evcc/provider"
 	"github.com/evcc-io/evcc/push"
@@ -33,6 +35,14 @@ const (
 	evVehicleDisconnect = "disconnect" // vehicle disconnected
 	evVehicleSoC        = "soc"        // vehicle soc progress
 
+	evChargerError      = "chargererror"      // charger failing
+	evMeterError        = "metererror"        // meter failing
+	evTargetMissed      = "targetmissed"      // target soc can't be reached in time
+	evVehicleIdentified = "vehicleidentified" // vehicle identified
+	evVehicleUnknown    = "vehicleunknown"    // vehicle could not be identified
+	evGridLimited       = "gridlimited"       // charge current limited by site load management
+	evGridUnlimited     = "gridunlimited"     // charge current no longer limited
+
 	pvTimer   = "pv"
 	pvEnable  = "enable"
 	pvDisable = "disable"
@@ -96,6 +106,7 @@ type LoadPoint struct {
 	Mode       api.ChargeMode `mapstructure:"mode"` // Charge mode, guarded by mutex
 
 	Title       string   `mapstructure:"title"`    // UI title
+	Priority    int      `mapstructure:"priority"` // Priority for surplus distribution, higher first
 	Phases      int      `mapstructure:"phases"`   // Charger enabled phases
 	ChargerRef  string   `mapstructure:"charger"`  // Charger reference
 	VehicleRef  string   `mapstructure:"vehicle"`  // Vehicle reference
@@ -104,6 +115,7 @@ type LoadPoint struct {
 		ChargeMeterRef string `mapstructure:"charge"` // Charge meter reference
 	}
 	SoC               SoCConfig
+	Plans             []plan.Plan `mapstructure:"plans"` // Recurring charging targets
 	OnDisconnect_     interface{} `mapstructure:"onDisconnect"`
 	OnIdentify_       interface{} `mapstructure:"onIdentify"`
 	Enable, Disable   ThresholdConfig
@@ -115,6 +127,8 @@ type LoadPoint struct {
 	GuardDuration time.Duration // charger enable/disable minimum holding time
 
 	enabled                bool      // Charger enabled state
+	siteLimited            bool      // Charge current limited by site load management
+	siteCurrentLimit       float64   // Site load management current limit
 	activePhases           int       // Charger active phases as used by vehicle
 	chargeCurrent          float64   // Charger current limit
 	guardUpdated           time.Time // Charger enabled/disabled timestamp
@@ -122,6 +136,11 @@ type LoadPoint struct {
 	vehicleConnected       time.Time // Vehicle connected timestamp
 	vehicleConnectedTicker *clock.Ticker
 	vehicleID              string
+	vehicleUnknown         bool            // Vehicle unknown notification sent
+	gridLimited            bool            // Charge current restricted by site limit
+	siteLimitCut           bool            // Last requested charge current cut by site limit
+	targetMissed           bool            // Target missed notification sent
+	deviceErrors           map[string]bool // Failing devices
 
 	charger     api.Charger
 	chargeTimer api.ChargeTimer
@@ -132,10 +151,12 @@ type LoadPoint struct {
 	vehicles     []api.Vehicle // Assigned vehicles
 	socEstimator *soc.Estimator
 	socTimer     *soc.Timer
+	planTime     time.Time // Armed occurrence of recurring plan
 
 	// cached state
 	status         api.ChargeStatus       // Charger status
 	remoteDemand   loadpoint.RemoteDemand // External status demand
+	remoteLimits   map[string]float64     // External transient current limits by source
 	chargePower    float64                // Charging power
 	chargeCurrents []float64              // Phase currents
 	connectedTime  time.Time              // Time when vehicle was connected
@@ -144,11 +165,21 @@ type LoadPoint struct {
 
 	// charge progress
 	vehicleSoc              float64       // Vehicle SoC
+	chargerSoc              bool          // Vehicle SoC provided by charger without configured vehicle
 	chargeDuration          time.Duration // Charge duration
 	chargedEnergy           float64       // Charged energy while connected in Wh
 	chargeRemainingDuration time.Duration // Remaining charge duration
 	chargeRemainingEnergy   float64       // Remaining charge energy in Wh
 	progress                *Progress     // Step-wise progress indicator
+
+	// charging session
+	savings        *Savings           // Site savings for session solar share and price
+	sessionStore   store.SessionStore // Optional session persistence
+	session        *store.Session     // Current charging session
+	sessionEnergy  float64            // Charged energy accounted to the session in Wh
+	sessionCharged float64            // Session energy in kWh
+	sessionSolar   float64            // Self-produced session energy in kWh
+	sessionCost    float64            // Session energy cost (e.g. EUR)
 }
 
 // NewLoadPointFromConfig creates a new loadpoint
@@ -187,6 +218,12 @@ func NewLoadPointFromConfig(log *util.Logger, cp configProvider, other map[strin
 		}
 	}
 
+	for _, p := range lp.Plans {
+		if err := plan.Validate(p); err != nil {
+			return nil, fmt.Errorf("plan: %w", err)
+		}
+	}
+
 	if lp.MinCurrent == 0 {
 		lp.log.WARN.Println("minCurrent must not be zero")
 	}
@@ -328,7 +365,9 @@ func (lp *LoadPoint) configureChargerType(charger api.Charger) {
 
 // pushEvent sends push messages to clients
 func (lp *LoadPoint) pushEvent(event string) {
-	lp.pushChan <- push.Event{Event: event}
+	if lp.pushChan != nil {
+		lp.pushChan <- push.Event{Event: event}
+	}
 }
 
 // publish sends values to UI and databases
@@ -377,6 +416,9 @@ func (lp *LoadPoint) evVehicleConnectHandler() {
 	// soc update reset
 	lp.socUpdated = time.Time{}
 
+	// notify unknown vehicle once per connection
+	lp.vehicleUnknown = false
+
 	// soc update reset on car change
 	if lp.socEstimator != nil {
 		lp.socEstimator.Reset()
@@ -394,6 +436,8 @@ func (lp *LoadPoint) evVehicleConnectHandler() {
 	// immediately allow pv mode activity
 	lp.elapsePVTimer()
 
+	lp.createSession()
+
 	lp.pushEvent(evVehicleConnect)
 }
 
@@ -405,6 +449,8 @@ func (lp *LoadPoint) evVehicleDisconnectHandler() {
 	lp.publish("chargedEnergy", lp.chargedEnergy)
 	lp.publish("connectedDuration", lp.clock.Since(lp.connectedTime))
 
+	lp.finishSession()
+
 	lp.pushEvent(evVehicleDisconnect)
 
 	// remove active vehicle if we have multiple vehicles
@@ -432,6 +478,9 @@ func (lp *LoadPoint) evVehicleDisconnectHandler() {
 
 	// reset timer when vehicle is removed
 	lp.socTimer.Reset()
+
+	// re-arm recurring plan for next session
+	lp.planTime = time.Time{}
 }
 
 // evVehicleSoCProgressHandler sends external start event
@@ -509,6 +558,7 @@ func (lp *LoadPoint) Prepare(uiChan chan<- util.Param, pushChan chan<- push.Even
 
 	// publish initial values
 	lp.publish("title", lp.Title)
+	lp.publish("priority", lp.Priority)
 	lp.publish("minCurrent", lp.MinCurrent)
 	lp.publish("maxCurrent", lp.MaxCurrent)
 	lp.publish("phases", lp.Phases)
@@ -519,6 +569,7 @@ func (lp *LoadPoint) Prepare(uiChan chan<- util.Param, pushChan chan<- push.Even
 	lp.publish("mode", lp.Mode)
 	lp.publish("targetSoC", lp.SoC.Target)
 	lp.publish("minSoC", lp.SoC.Min)
+	lp.publish("plans", lp.Plans)
 	lp.Unlock()
 
 	// always treat single vehicle as attached to allow poll mode: always
@@ -566,12 +617,53 @@ func (lp *LoadPoint) syncCharger() {
 	}
 
 	if err != nil {
+		countError("charger", lp.Title)
 		lp.log.ERROR.Printf("charger: %v", err)
 	}
 }
 
+// setSiteCurrentLimit applies the site's load management current limit.
+// If charging above limit, the charger is throttled immediately.
+func (lp *LoadPoint) setSiteCurrentLimit(current float64) {
+	lp.siteLimited = true
+	lp.siteCurrentLimit = current
+	lp.publish("siteCurrentLimit", current)
+
+	if lp.enabled && lp.chargeCurrent > current {
+		lp.log.DEBUG.Printf("site current limit: %.3gA", current)
+		// setLimit caps the current charge current to the site limit
+		if err := lp.setLimit(lp.chargeCurrent, true); err != nil {
+			lp.log.ERROR.Println(err)
+		}
+	}
+}
+
+// resetSiteCurrentLimit releases the site's load management current limit
+func (lp *LoadPoint) resetSiteCurrentLimit() {
+	lp.siteLimited = false
+	lp.publish("siteCurrentLimit", lp.GetMaxCurrent())
+
+	lp.siteLimitCut = false
+	lp.setGridLimited(false)
+}
+
 // setLimit applies charger current limits and enables/disables accordingly
 func (lp *LoadPoint) setLimit(chargeCurrent float64, force bool) error {
+	// honour external current limits
+	if limit := lp.remoteCurrentLimit(); limit > 0 && chargeCurrent > limit {
+		chargeCurrent = limit
+	}
+
+	// honour site load management
+	// the limit only cuts charging if the vehicle is charging or prevented from starting
+	lp.siteLimitCut = lp.siteLimited && chargeCurrent > lp.siteCurrentLimit &&
+		(lp.charging() || lp.siteCurrentLimit < lp.GetMinCurrent())
+
+	if lp.siteLimited && chargeCurrent > lp.siteCurrentLimit {
+		chargeCurrent = lp.siteCurrentLimit
+		force = force || lp.enabled && chargeCurrent < lp.GetMinCurrent()
+	}
+
 	// set current
 	if chargeCurrent != lp.chargeCurrent && chargeCurrent >= lp.GetMinCurrent() {
 		var err error
@@ -649,18 +741,18 @@ func (lp *LoadPoint) setStatus(status api.ChargeStatus) {
 }
 
 // targetSocReached checks if target is configured and reached.
-// If vehicle is not configured this will always return false
+// If vehicle is not configured and charger does not provide soc this will always return false
 func (lp *LoadPoint) targetSocReached() bool {
-	return lp.vehicle != nil &&
+	return (lp.vehicle != nil || lp.chargerSoc) &&
 		lp.SoC.Target > 0 &&
 		lp.SoC.Target < 100 &&
 		lp.vehicleSoc >= float64(lp.SoC.Target)
 }
 
 // minSocNotReached checks if minimum is configured and not reached.
-// If vehicle is not configured this will always return true
+// If vehicle is not configured and charger does not provide soc this will always return false
 func (lp *LoadPoint) minSocNotReached() bool {
-	return lp.vehicle != nil &&
+	return (lp.vehicle != nil || lp.chargerSoc) &&
 		lp.SoC.Min > 0 &&
 		lp.vehicleSoc < float64(lp.SoC.Min)
 }
@@ -696,6 +788,25 @@ func (lp *LoadPoint) climateActive() bool {
 	return false
 }
 
+// remoteCurrentLimit returns the lowest external current limit, zero if not limited
+func (lp *LoadPoint) remoteCurrentLimit() float64 {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.lowestRemoteLimit()
+}
+
+// lowestRemoteLimit returns the lowest external current limit. Must be called with lock held.
+func (lp *LoadPoint) lowestRemoteLimit() float64 {
+	var res float64
+	for _, limit := range lp.remoteLimits {
+		if res == 0 || limit < res {
+			res = limit
+		}
+	}
+
+	return res
+}
+
 // remoteControlled returns true if remote control status is active
 func (lp *LoadPoint) remoteControlled(demand loadpoint.RemoteDemand) bool {
 	lp.Lock()
@@ -730,6 +841,8 @@ func (lp *LoadPoint) identifyVehicle() {
 	if id != "" {
 		if vehicle := lp.selectVehicleByID(id); vehicle != nil {
 			lp.setActiveVehicle(vehicle)
+		} else {
+			lp.setVehicleUnknown()
 		}
 	}
 }
@@ -779,6 +892,9 @@ func (lp *LoadPoint) setActiveVehicle(vehicle api.Vehicle) {
 	}
 	lp.log.INFO.Printf("vehicle updated: %s -> %s", from, to)
 
+	// plans may differ between vehicles
+	lp.disarmPlan()
+
 	if lp.vehicle = vehicle; vehicle != nil {
 		lp.socEstimator = soc.NewEstimator(lp.log, lp.charger, vehicle, lp.SoC.Estimate)
 
@@ -788,6 +904,11 @@ func (lp *LoadPoint) setActiveVehicle(vehicle api.Vehicle) {
 
 		lp.applyAction(vehicle.OnIdentified())
 
+		// single vehicles are assigned, not identified
+		if len(lp.vehicles) > 1 {
+			lp.pushEvent(evVehicleIdentified)
+		}
+
 		lp.setVehiclePhases()
 
 		lp.progress.Reset()
@@ -860,6 +981,10 @@ func (lp *LoadPoint) updateChargerStatus() error {
 		if prevStatus == api.StatusNone {
 			lp.connectedTime = lp.clock.Now()
 			lp.publish("connectedDuration", time.Duration(0))
+
+			if lp.connected() {
+				lp.createSession()
+			}
 		}
 
 		// changed from A - connected
@@ -1228,9 +1353,11 @@ func (lp *LoadPoint) updateChargePower() {
 		}
 
 		return nil
-	}, retryOptions...)
+	}, retryOptionsWithMetrics("charge", lp.Title)...)
 
+	lp.setDeviceError("chargeMeter", evMeterError, err)
 	if err != nil {
+		countError("charge", lp.Title)
 		lp.log.ERROR.Printf("charge meter: %v", err)
 	}
 }
@@ -1323,9 +1450,33 @@ func (lp *LoadPoint) socProvidedByCharger() bool {
 	return false
 }
 
+// publishChargerSoC publishes the soc provided by the charger if no vehicle is configured
+func (lp *LoadPoint) publishChargerSoC() {
+	lp.chargerSoc = false
+
+	charger, ok := lp.charger.(api.Battery)
+	if !ok || !lp.connected() {
+		return
+	}
+
+	f, err := charger.SoC()
+	if err != nil {
+		if !errors.Is(err, api.ErrNotAvailable) && !errors.Is(err, api.ErrMustRetry) {
+			lp.log.ERROR.Printf("charger soc: %v", err)
+		}
+		return
+	}
+
+	lp.chargerSoc = true
+	lp.vehicleSoc = math.Trunc(f)
+	lp.log.DEBUG.Printf("vehicle soc: %.0f%% (charger)", lp.vehicleSoc)
+	lp.publish("vehicleSoC", lp.vehicleSoc)
+}
+
 // publish state of charge, remaining charge duration and range
 func (lp *LoadPoint) publishSoCAndRange() {
 	if lp.socEstimator == nil {
+		lp.publishChargerSoC()
 		return
 	}
 
@@ -1396,11 +1547,14 @@ func (lp *LoadPoint) Update(sitePower float64, cheap bool, batteryBuffered bool)
 	// publish providerLogins
 	lp.publishProviderLogins()
 
-	// read and publish status
-	if err := lp.updateChargerStatus(); err != nil {
+	// read and publish status, notify once the error persists past retries
+	if err := retry.Do(lp.updateChargerStatus, retryOptionsWithMetrics("charger", lp.Title)...); err != nil {
+		lp.setDeviceError("charger", evChargerError, err)
+		countError("charger", lp.Title)
 		lp.log.ERROR.Printf("charger: %v", err)
 		return
 	}
+	lp.setDeviceError("charger", evChargerError, nil)
 
 	lp.publish("connected", lp.connected())
 	lp.publish("charging", lp.charging())
@@ -1414,6 +1568,8 @@ func (lp *LoadPoint) Update(sitePower float64, cheap bool, batteryBuffered bool)
 		// find vehicle by status for a couple of minutes after connecting
 		if lp.vehicleUnidentified() {
 			lp.identifyVehicleByStatus()
+		} else if lp.vehicle == nil && len(lp.vehicles) > 1 && !lp.vehicleConnected.IsZero() {
+			lp.setVehicleUnknown()
 		}
 	}
 
@@ -1430,6 +1586,9 @@ func (lp *LoadPoint) Update(sitePower float64, cheap bool, batteryBuffered bool)
 	// track if remote disabled is actually active
 	remoteDisabled := loadpoint.RemoteEnable
 
+	// arm next occurrence of recurring plans
+	lp.updatePlan()
+
 	// reset detection if soc timer needs be deactivated after evaluating the loading strategy
 	lp.socTimer.MustValidateDemand()
 
@@ -1448,7 +1607,11 @@ func (lp *LoadPoint) Update(sitePower float64, cheap bool, batteryBuffered bool)
 			targetCurrent = lp.GetMinCurrent()
 		}
 		err = lp.setLimit(targetCurrent, true)
-		lp.socTimer.Reset() // once SoC is reached, the target charge request is removed
+		// once SoC is reached, the target charge request is removed
+		// recurring plans remain armed until their deadline has passed
+		if !lp.planArmed() {
+			lp.socTimer.Reset()
+		}
 
 	// OCPP has priority over target charging
 	case lp.remoteControlled(loadpoint.RemoteHardDisable):
@@ -1506,6 +1669,14 @@ func (lp *LoadPoint) Update(sitePower float64, cheap bool, batteryBuffered bool)
 		err = lp.setLimit(targetCurrent, required)
 	}
 
+	// notify if site load management restricts charging
+	if err == nil {
+		lp.setGridLimited(lp.siteLimitCut)
+	}
+
+	// notify if target soc can't be reached in time
+	lp.updateTargetMissed()
+
 	// stop an active target charging session if not currently evaluated
 	if !lp.socTimer.DemandValidated() {
 		lp.socTimer.Stop()
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/plan"
 )
 
 // Controller gives access to loadpoint
@@ -46,10 +47,18 @@ type API interface {
 	// SetPhases sets the enabled phases
 	SetPhases(int) error
 
+	// GetTargetTime returns the target charge time, zero if not set
+	GetTargetTime() time.Time
 	// SetTargetCharge sets the charge targetSoC
 	SetTargetCharge(time.Time, int)
+	// GetPlans returns the recurring charging plans
+	GetPlans() []plan.Plan
+	// SetPlans sets the recurring charging plans
+	SetPlans([]plan.Plan) error
 	// RemoteControl sets remote status demand
 	RemoteControl(string, RemoteDemand)
+	// RemoteCurrentLimit sets a transient charge current limit by source, zero removes the limit
+	RemoteCurrentLimit(string, float64)
 
 	//
 	// power and energy
@@ -78,4 +87,6 @@ type API interface {
 	GetRemainingDuration() time.Duration
 	// GetRemainingEnergy is the remaining charge energy in Wh
 	GetRemainingEnergy() float64
+	// GetChargedEnergy is the energy charged in the current session in Wh
+	GetChargedEnergy() float64
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/evcc-io/evcc/api"
 	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/plan"
 	"github.com/evcc-io/evcc/core/wrapper"
 )
 
@@ -109,6 +110,13 @@ func (lp *LoadPoint) SetPhases(phases int) error {
 	return lp.scalePhases(phases)
 }
 
+// GetTargetTime returns the target charge time, zero if not set
+func (lp *LoadPoint) GetTargetTime() time.Time {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.socTimer.Time
+}
+
 // SetTargetCharge sets loadpoint charge targetSoC
 func (lp *LoadPoint) SetTargetCharge(finishAt time.Time, soc int) {
 	lp.Lock()
@@ -120,6 +128,11 @@ func (lp *LoadPoint) SetTargetCharge(finishAt time.Time, soc int) {
 	if lp.socTimer.Time != finishAt || lp.SoC.Target != soc {
 		lp.socTimer.Set(finishAt)
 
+		// manual target replaces armed plan, removing it skips the armed occurrence
+		if !finishAt.IsZero() {
+			lp.planTime = time.Time{}
+		}
+
 		// don't remove soc
 		if !finishAt.IsZero() {
 			lp.publish("targetTimeHourSuggestion", finishAt.Hour())
@@ -129,6 +142,36 @@ func (lp *LoadPoint) SetTargetCharge(finishAt time.Time, soc int) {
 	}
 }
 
+// GetPlans returns the loadpoint's recurring charging plans
+func (lp *LoadPoint) GetPlans() []plan.Plan {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.Plans
+}
+
+// SetPlans sets the loadpoint's recurring charging plans
+func (lp *LoadPoint) SetPlans(plans []plan.Plan) error {
+	for _, p := range plans {
+		if err := plan.Validate(p); err != nil {
+			return err
+		}
+	}
+
+	lp.Lock()
+	defer lp.Unlock()
+
+	lp.log.DEBUG.Printf("set plans: %v", plans)
+
+	lp.Plans = plans
+	lp.publish("plans", plans)
+
+	// re-arm from updated plans
+	lp.disarmPlan()
+	lp.requestUpdate()
+
+	return nil
+}
+
 // RemoteControl sets remote status demand
 func (lp *LoadPoint) RemoteControl(source string, demand loadpoint.RemoteDemand) {
 	lp.Lock()
@@ -147,6 +190,31 @@ func (lp *LoadPoint) RemoteControl(source string, demand loadpoint.RemoteDemand)
 	}
 }
 
+// RemoteCurrentLimit sets a transient charge current limit by source, zero removes the limit.
+// The configured max current remains unchanged.
+func (lp *LoadPoint) RemoteCurrentLimit(source string, current float64) {
+	lp.Lock()
+	defer lp.Unlock()
+
+	if limit, ok := lp.remoteLimits[source]; ok && limit == current || !ok && current <= 0 {
+		return
+	}
+
+	lp.log.DEBUG.Printf("remote current limit: %.3gA (%s)", current, source)
+
+	if current > 0 {
+		if lp.remoteLimits == nil {
+			lp.remoteLimits = make(map[string]float64)
+		}
+		lp.remoteLimits[source] = current
+	} else {
+		delete(lp.remoteLimits, source)
+	}
+
+	lp.publish("remoteCurrentLimit", lp.lowestRemoteLimit())
+	lp.requestUpdate()
+}
+
 // HasChargeMeter determines if a physical charge meter is attached
 func (lp *LoadPoint) HasChargeMeter() bool {
 	_, isWrapped := lp.chargeMeter.(*wrapper.ChargeMeter)
@@ -245,3 +313,10 @@ func (lp *LoadPoint) GetRemainingEnergy() float64 {
 	defer lp.Unlock()
 	return lp.chargeRemainingEnergy
 }
+
+// GetChargedEnergy is the energy charged in the current session in Wh
+func (lp *LoadPoint) GetChargedEnergy() float64 {
+	lp.Lock()
+	defer lp.Unlock()
+	return lp.chargedEnergy
+}
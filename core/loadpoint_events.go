@@ -0,0 +1,64 @@
+package core
+
+// setDeviceError publishes the device's error and sends a notification when it starts failing
+func (lp *LoadPoint) setDeviceError(device, event string, err error) {
+	failing := err != nil
+	if lp.deviceErrors[device] == failing {
+		return
+	}
+
+	if lp.deviceErrors == nil {
+		lp.deviceErrors = make(map[string]bool)
+	}
+	lp.deviceErrors[device] = failing
+
+	var msg string
+	if failing {
+		msg = err.Error()
+	}
+	lp.publish(device+"Error", msg)
+
+	if failing {
+		lp.pushEvent(event)
+	}
+}
+
+// setVehicleUnknown sends a notification once per connection if the vehicle could not be identified
+func (lp *LoadPoint) setVehicleUnknown() {
+	if lp.vehicleUnknown {
+		return
+	}
+
+	lp.vehicleUnknown = true
+	lp.log.WARN.Println("vehicle unknown")
+	lp.pushEvent(evVehicleUnknown)
+}
+
+// setGridLimited sends a notification when site load management starts or stops restricting the charge current
+func (lp *LoadPoint) setGridLimited(limited bool) {
+	if lp.gridLimited == limited {
+		return
+	}
+
+	lp.gridLimited = limited
+	lp.publish("gridLimited", limited)
+
+	if limited {
+		lp.pushEvent(evGridLimited)
+	} else {
+		lp.pushEvent(evGridUnlimited)
+	}
+}
+
+// updateTargetMissed sends a notification once the target charge can't be reached in time
+func (lp *LoadPoint) updateTargetMissed() {
+	missed := lp.socTimer.Missed()
+	if lp.targetMissed == missed {
+		return
+	}
+
+	lp.targetMissed = missed
+	if missed {
+		lp.pushEvent(evTargetMissed)
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/mock"
+	"github.com/evcc-io/evcc/push"
+	"github.com/evcc-io/evcc/util"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDeviceErrorEvents(t *testing.T) {
+	pushChan := make(chan push.Event, 10)
+
+	lp := NewLoadPoint(util.NewLogger("foo"))
+	lp.pushChan = pushChan
+
+	// notify once when starting to fail
+	lp.setDeviceError("charger", evChargerError, errors.New("foo"))
+	lp.setDeviceError("charger", evChargerError, errors.New("foo"))
+	lp.setDeviceError("charger", evChargerError, nil)
+	lp.setDeviceError("charger", evChargerError, errors.New("foo"))
+
+	if n := len(pushChan); n != 2 {
+		t.Fatalf("expected 2 events, got %d", n)
+	}
+
+	if ev := <-pushChan; ev.Event != evChargerError {
+		t.Errorf("unexpected event %s", ev.Event)
+	}
+}
+
+func TestGridLimitedEvents(t *testing.T) {
+	pushChan := make(chan push.Event, 10)
+
+	lp := NewLoadPoint(util.NewLogger("foo"))
+	lp.pushChan = pushChan
+
+	for _, limited := range []bool{false, true, true, false} {
+		lp.setGridLimited(limited)
+	}
+
+	for _, expected := range []string{evGridLimited, evGridUnlimited} {
+		if ev := <-pushChan; ev.Event != expected {
+			t.Errorf("expected %s, got %s", expected, ev.Event)
+		}
+	}
+
+	if n := len(pushChan); n != 0 {
+		t.Errorf("unexpected events: %d", n)
+	}
+}
+
+func TestSiteLimitCut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	tc := []struct {
+		title  string
+		status api.ChargeStatus
+		limit  float64
+		cut    bool
+	}{
+		{"charging", api.StatusC, 10, true},
+		{"idle above min current", api.StatusB, 10, false},
+		{"prevented from starting", api.StatusB, 0, true},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		charger := mock.NewMockCharger(ctrl)
+		charger.EXPECT().MaxCurrent(gomock.Any()).Return(nil).AnyTimes()
+		charger.EXPECT().Enable(gomock.Any()).Return(nil).AnyTimes()
+
+		lp := NewLoadPoint(util.NewLogger("foo"))
+		lp.charger = charger
+		lp.status = tc.status
+		lp.MinCurrent = 6
+		lp.MaxCurrent = 16
+		lp.siteLimited = true
+		lp.siteCurrentLimit = tc.limit
+
+		if err := lp.setLimit(16, true); err != nil {
+			t.Fatal(err)
+		}
+
+		if lp.siteLimitCut != tc.cut {
+			t.Errorf("expected cut %v, got %v", tc.cut, lp.siteLimitCut)
+		}
+	}
+}
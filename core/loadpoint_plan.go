@@ -0,0 +1,78 @@
+package core
+
+import (
+	"time"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/plan"
+)
+
+// activePlans returns the active vehicle's recurring plans if defined, otherwise the loadpoint's plans
+func (lp *LoadPoint) activePlans() []plan.Plan {
+	if lp.vehicle != nil {
+		if plans := lp.vehicle.Plans(); len(plans) > 0 {
+			return plans
+		}
+	}
+
+	return lp.Plans
+}
+
+// planArmed returns true if the target charge timer is set from a recurring plan
+func (lp *LoadPoint) planArmed() bool {
+	return !lp.planTime.IsZero() && lp.socTimer.Time.Equal(lp.planTime)
+}
+
+// disarmPlan removes the target charge armed from a recurring plan
+func (lp *LoadPoint) disarmPlan() {
+	if lp.planArmed() {
+		lp.socTimer.Reset()
+	}
+
+	lp.planTime = time.Time{}
+}
+
+// updatePlan arms the target charge timer with the next occurrence of the recurring plans
+func (lp *LoadPoint) updatePlan() {
+	lp.Lock()
+	defer lp.Unlock()
+
+	plans := lp.activePlans()
+	if len(plans) == 0 {
+		return
+	}
+
+	targetTime := lp.socTimer.Time
+
+	// manual target charge takes precedence
+	if !targetTime.IsZero() && !lp.planArmed() {
+		return
+	}
+
+	after := lp.clock.Now()
+
+	switch {
+	case targetTime.IsZero():
+		// armed occurrence has been removed, skip to the following one
+		if lp.planTime.After(after) {
+			after = lp.planTime
+		}
+
+	case targetTime.After(after) || lp.status == api.StatusC:
+		// armed occurrence pending or still charging
+		return
+	}
+
+	ts, soc, err := plan.Next(plans, after)
+	if err != nil {
+		lp.log.ERROR.Printf("plan: %v", err)
+		return
+	}
+
+	lp.log.DEBUG.Printf("plan: arm target charge: %d @ %v", soc, ts)
+
+	lp.planTime = ts
+	lp.socTimer.Set(ts)
+	lp.publish("targetTimeHourSuggestion", ts.Hour())
+	lp.setTargetSoC(soc)
+}
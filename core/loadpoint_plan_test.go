@@ -0,0 +1,89 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/plan"
+	"github.com/evcc-io/evcc/core/soc"
+	"github.com/evcc-io/evcc/mock"
+	"github.com/evcc-io/evcc/util"
+	"github.com/golang/mock/gomock"
+)
+
+func TestUpdatePlan(t *testing.T) {
+	clck := clock.NewMock()
+	// Wednesday
+	clck.Set(time.Date(2021, 9, 1, 12, 0, 0, 0, time.Local))
+
+	lp := NewLoadPoint(util.NewLogger("foo"))
+	lp.clock = clck
+	lp.status = api.StatusB
+	lp.socTimer = soc.NewTimer(lp.log, &adapter{LoadPoint: lp})
+	lp.Plans = []plan.Plan{{Days: "Mon-Fri", Time: "07:00", SoC: 80}}
+
+	thursday := time.Date(2021, 9, 2, 7, 0, 0, 0, time.Local)
+	friday := thursday.AddDate(0, 0, 1)
+
+	// arm next occurrence
+	lp.updatePlan()
+	if !lp.socTimer.Time.Equal(thursday) || lp.SoC.Target != 80 {
+		t.Fatalf("expected %v at 80%%, got %v at %d%%", thursday, lp.socTimer.Time, lp.SoC.Target)
+	}
+
+	// removing armed target skips to following occurrence
+	lp.socTimer.Reset()
+	lp.updatePlan()
+	if !lp.socTimer.Time.Equal(friday) {
+		t.Fatalf("expected %v, got %v", friday, lp.socTimer.Time)
+	}
+
+	// manual target takes precedence
+	manual := friday.Add(time.Hour)
+	lp.SetTargetCharge(manual, 90)
+	lp.updatePlan()
+	if !lp.socTimer.Time.Equal(manual) {
+		t.Fatalf("expected %v, got %v", manual, lp.socTimer.Time)
+	}
+
+	// missed occurrence re-arms once passed
+	lp.SetTargetCharge(time.Time{}, 0)
+	lp.updatePlan()
+	clck.Set(friday.Add(time.Minute))
+	lp.updatePlan()
+	if next := friday.AddDate(0, 0, 3); !lp.socTimer.Time.Equal(next) {
+		t.Fatalf("expected %v, got %v", next, lp.socTimer.Time)
+	}
+}
+
+func TestActivePlansDecoratedVehicle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	vehiclePlans := []plan.Plan{{Days: "Sat", Time: "09:00", SoC: 100}}
+
+	vhc := mock.NewMockVehicle(ctrl)
+	vhc.EXPECT().Plans().Return(vehiclePlans).AnyTimes()
+
+	lp := NewLoadPoint(util.NewLogger("foo"))
+	lp.Plans = []plan.Plan{{Days: "Mon-Fri", Time: "07:00", SoC: 80}}
+
+	// decorated vehicles only embed api.Vehicle
+	lp.vehicle = &struct {
+		api.Vehicle
+		api.ChargeState
+	}{
+		Vehicle: vhc,
+	}
+
+	if plans := lp.activePlans(); !reflect.DeepEqual(plans, vehiclePlans) {
+		t.Errorf("expected vehicle plans %v, got %v", vehiclePlans, plans)
+	}
+
+	lp.vehicle = nil
+	if plans := lp.activePlans(); !reflect.DeepEqual(plans, lp.Plans) {
+		t.Errorf("expected loadpoint plans %v, got %v", lp.Plans, plans)
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/store"
+)
+
+// createSession starts a new charging session
+func (lp *LoadPoint) createSession() {
+	if lp.sessionStore == nil {
+		return
+	}
+
+	lp.session = &store.Session{
+		LoadPoint: lp.Title,
+		Created:   lp.clock.Now(),
+	}
+
+	if mt, ok := lp.chargeMeter.(api.MeterEnergy); ok {
+		if f, err := mt.TotalEnergy(); err == nil {
+			lp.session.MeterStart = f
+		} else {
+			lp.log.ERROR.Printf("session meter: %v", err)
+		}
+	}
+
+	lp.sessionEnergy = lp.chargedEnergy
+	lp.sessionCharged = 0
+	lp.sessionSolar = 0
+	lp.sessionCost = 0
+}
+
+// updateSession accounts the loadpoint's charged energy since last update
+// using the site's current self-produced share and prices
+func (lp *LoadPoint) updateSession() {
+	if lp.session == nil || lp.savings == nil {
+		return
+	}
+
+	added := (lp.chargedEnergy - lp.sessionEnergy) / 1e3
+	lp.sessionEnergy = lp.chargedEnergy
+
+	if added <= 0 {
+		return
+	}
+
+	share, gridPrice, feedinPrice := lp.savings.energyMix()
+
+	lp.sessionCharged += added
+	lp.sessionSolar += added * share
+	lp.sessionCost += added*(1-share)*gridPrice + added*share*feedinPrice
+}
+
+// finishSession completes and stores the charging session
+func (lp *LoadPoint) finishSession() {
+	if lp.session == nil {
+		return
+	}
+
+	lp.updateSession()
+
+	s := lp.session
+	lp.session = nil
+
+	s.Finished = lp.clock.Now()
+	s.Identifier = lp.vehicleID
+	if lp.vehicle != nil {
+		s.Vehicle = lp.vehicle.Title()
+	}
+
+	// prefer meter readings over charge rater
+	s.ChargedEnergy = lp.chargedEnergy / 1e3
+	if mt, ok := lp.chargeMeter.(api.MeterEnergy); ok {
+		if f, err := mt.TotalEnergy(); err == nil {
+			s.MeterStop = f
+			s.ChargedEnergy = s.MeterStop - s.MeterStart
+		} else {
+			lp.log.ERROR.Printf("session meter: %v", err)
+		}
+	}
+
+	// solar share and price of the loadpoint's own charged energy
+	if lp.sessionCharged > 0 {
+		s.SolarPercentage = 100 * lp.sessionSolar / lp.sessionCharged
+		s.Price = s.ChargedEnergy * lp.sessionCost / lp.sessionCharged
+	}
+
+	lp.log.DEBUG.Printf("session: %.3gkWh charged", s.ChargedEnergy)
+
+	if err := lp.sessionStore.AddSession(*s); err != nil {
+		lp.log.ERROR.Printf("session: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/core/store"
+	"github.com/evcc-io/evcc/util"
+)
+
+type sessionStore struct {
+	sessions []store.Session
+}
+
+func (s *sessionStore) AddSession(session store.Session) error {
+	s.sessions = append(s.sessions, session)
+	return nil
+}
+
+func TestSessionEnergyMix(t *testing.T) {
+	clck := clock.NewMock()
+	db := &sessionStore{}
+
+	savings := &Savings{
+		clock:           clck,
+		lastGridPrice:   0.3,
+		lastFeedInPrice: 0.1,
+	}
+
+	newLoadpoint := func(title string) *LoadPoint {
+		lp := &LoadPoint{
+			log:          util.NewLogger("foo"),
+			clock:        clck,
+			chargeMeter:  &Null{},
+			Title:        title,
+			savings:      savings,
+			sessionStore: db,
+		}
+		lp.createSession()
+		return lp
+	}
+
+	lp1 := newLoadpoint("lp1")
+	lp2 := newLoadpoint("lp2")
+
+	// lp1 charges 10kWh from pv only, lp2 idle
+	savings.share = 1
+	lp1.chargedEnergy = 10e3
+	lp1.updateSession()
+	lp2.updateSession()
+
+	// lp1 and lp2 charge 10kWh each from grid
+	savings.share = 0
+	lp1.chargedEnergy = 20e3
+	lp2.chargedEnergy = 10e3
+	lp1.updateSession()
+	lp2.updateSession()
+
+	lp1.finishSession()
+	lp2.finishSession()
+
+	if len(db.sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %v", db.sessions)
+	}
+
+	tc := []struct {
+		solar, price float64
+	}{
+		{50, 10*0.1 + 10*0.3},
+		{0, 10 * 0.3},
+	}
+
+	for i, tc := range tc {
+		s := db.sessions[i]
+		if !compareWithTolerane(s.SolarPercentage, tc.solar) || !compareWithTolerane(s.Price, tc.price) {
+			t.Errorf("%s: expected %.0f%% at %.2f, got %.0f%% at %.2f", s.LoadPoint, tc.solar, tc.price, s.SolarPercentage, s.Price)
+		}
+	}
+}
@@ -372,6 +372,7 @@ func TestDisableAndEnableAtTargetSoC(t *testing.T) {
 
 	// wrap vehicle with estimator
 	vehicle.EXPECT().Capacity().Return(int64(10))
+	vehicle.EXPECT().Plans().AnyTimes()
 	socEstimator := soc.NewEstimator(util.NewLogger("foo"), charger, vehicle, false)
 
 	lp := &LoadPoint{
@@ -750,6 +751,59 @@ func TestMinSoC(t *testing.T) {
 	}
 }
 
+func TestChargerSoC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	charger := &struct {
+		*mock.MockCharger
+		*mock.MockBattery
+	}{
+		mock.NewMockCharger(ctrl),
+		mock.NewMockBattery(ctrl),
+	}
+
+	lp := &LoadPoint{
+		log:     util.NewLogger("foo"),
+		charger: charger,
+		status:  api.StatusB,
+		SoC: SoCConfig{
+			Min:    20,
+			Target: 80,
+		},
+	}
+
+	// soc not provided by charger
+	charger.MockBattery.EXPECT().SoC().Return(0.0, api.ErrNotAvailable)
+	lp.publishSoCAndRange()
+
+	if lp.targetSocReached() || lp.minSocNotReached() {
+		t.Error("unexpected soc evaluation without charger soc")
+	}
+
+	// soc provided by charger
+	charger.MockBattery.EXPECT().SoC().Return(85.0, nil)
+	lp.publishSoCAndRange()
+
+	if !lp.targetSocReached() {
+		t.Error("expected target soc reached")
+	}
+
+	charger.MockBattery.EXPECT().SoC().Return(10.0, nil)
+	lp.publishSoCAndRange()
+
+	if !lp.minSocNotReached() {
+		t.Error("expected min soc not reached")
+	}
+
+	// disconnected
+	lp.status = api.StatusA
+	lp.publishSoCAndRange()
+
+	if lp.minSocNotReached() {
+		t.Error("unexpected soc evaluation when disconnected")
+	}
+}
+
 func TestVehicleDetectByID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -980,3 +1034,38 @@ func TestVehiclePhases(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoteCurrentLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	charger := mock.NewMockCharger(ctrl)
+
+	lp := NewLoadPoint(util.NewLogger("foo"))
+	lp.charger = charger
+	lp.MinCurrent = 6
+	lp.MaxCurrent = 16
+
+	// lowest limit applies
+	lp.RemoteCurrentLimit("foo", 12)
+	lp.RemoteCurrentLimit("bar", 10)
+
+	charger.EXPECT().MaxCurrent(int64(10)).Return(nil)
+	charger.EXPECT().Enable(true).Return(nil)
+
+	if err := lp.setLimit(16, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// removing limit releases the next one
+	lp.RemoteCurrentLimit("bar", 0)
+
+	charger.EXPECT().MaxCurrent(int64(12)).Return(nil)
+
+	if err := lp.setLimit(16, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if lp.GetMaxCurrent() != 16 {
+		t.Errorf("max current modified: %.3gA", lp.GetMaxCurrent())
+	}
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"github.com/avast/retry-go/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	errorMetric *prometheus.CounterVec
+	retryMetric *prometheus.CounterVec
+)
+
+func init() {
+	labels := []string{"device", "loadpoint"}
+
+	errorMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "evcc",
+		Subsystem: "device",
+		Name:      "errors_total",
+		Help:      "Total count of meter and charger errors",
+	}, labels)
+
+	retryMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "evcc",
+		Subsystem: "device",
+		Name:      "retries_total",
+		Help:      "Total count of meter and charger retry attempts",
+	}, labels)
+
+	prometheus.MustRegister(errorMetric, retryMetric)
+}
+
+// countError increments the device's error counter
+func countError(device, loadpoint string) {
+	errorMetric.WithLabelValues(device, loadpoint).Inc()
+}
+
+// retryOptionsWithMetrics returns the default retry options counting retry attempts for the device
+func retryOptionsWithMetrics(device, loadpoint string) []retry.Option {
+	return append([]retry.Option{
+		retry.OnRetry(func(n uint, _ error) {
+			// last attempt is not retried
+			if n < retryAttempts-1 {
+				retryMetric.WithLabelValues(device, loadpoint).Inc()
+			}
+		}),
+	}, retryOptions...)
+}
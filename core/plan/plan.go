@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/util"
+)
+
+// Plan is a weekly recurring charging target
+type Plan = api.Plan
+
+// parse returns the plan's weekdays and time of day
+func parse(p Plan) ([]time.Weekday, time.Duration, error) {
+	days, err := util.ParseDays(p.Days)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(days) == 0 {
+		days = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	}
+
+	tod, err := time.Parse("15:04", p.Time)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid time: %s", p.Time)
+	}
+
+	return days, time.Duration(tod.Hour())*time.Hour + time.Duration(tod.Minute())*time.Minute, nil
+}
+
+// Validate checks the plan for errors
+func Validate(p Plan) error {
+	if _, _, err := parse(p); err != nil {
+		return err
+	}
+
+	if p.SoC <= 0 || p.SoC > 100 {
+		return fmt.Errorf("invalid soc: %d", p.SoC)
+	}
+
+	return nil
+}
+
+// next returns the plan's first occurrence strictly after the given time
+func next(p Plan, after time.Time) (time.Time, error) {
+	days, tod, err := parse(p)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// check today and the following week
+	y, m, d := after.Date()
+	for i := 0; i <= 7; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, after.Location())
+
+		for _, wd := range days {
+			if wd != day.Weekday() {
+				continue
+			}
+
+			ts := time.Date(y, m, d+i, 0, 0, 0, 0, after.Location()).Add(tod)
+			if ts.After(after) {
+				return ts, nil
+			}
+		}
+	}
+
+	return time.Time{}, errors.New("no occurrence")
+}
+
+// Next returns the earliest occurrence of all plans strictly after the given time.
+// Plans are expected to be validated on configuration.
+func Next(plans []Plan, after time.Time) (time.Time, int, error) {
+	var (
+		res time.Time
+		soc int
+	)
+
+	for _, p := range plans {
+		ts, err := next(p, after)
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("%v: %w", p, err)
+		}
+
+		if res.IsZero() || ts.Before(res) {
+			res = ts
+			soc = p.SoC
+		}
+	}
+
+	if res.IsZero() {
+		return res, 0, errors.New("no plans")
+	}
+
+	return res, soc, nil
+}
@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.Local)
+
+	tc := []struct {
+		plan Plan
+		next time.Time
+	}{
+		{Plan{Days: "Mon-Fri", Time: "07:00", SoC: 80}, time.Date(2021, 9, 2, 7, 0, 0, 0, time.Local)},
+		{Plan{Days: "Mon-Fri", Time: "18:30", SoC: 80}, time.Date(2021, 9, 1, 18, 30, 0, 0, time.Local)},
+		{Plan{Days: "Sat,Sun", Time: "09:00", SoC: 80}, time.Date(2021, 9, 4, 9, 0, 0, 0, time.Local)},
+		{Plan{Days: "Wed", Time: "12:00", SoC: 80}, time.Date(2021, 9, 8, 12, 0, 0, 0, time.Local)},
+		{Plan{Time: "06:00", SoC: 80}, time.Date(2021, 9, 2, 6, 0, 0, 0, time.Local)},
+	}
+
+	for _, tc := range tc {
+		ts, err := next(tc.plan, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !ts.Equal(tc.next) {
+			t.Errorf("%v: expected %v, got %v", tc.plan, tc.next, ts)
+		}
+	}
+}
+
+func TestPlanValidate(t *testing.T) {
+	for _, p := range []Plan{
+		{Days: "Foo", Time: "07:00", SoC: 80},
+		{Days: "Mon", Time: "7", SoC: 80},
+		{Days: "Mon", Time: "07:00", SoC: 0},
+	} {
+		if err := Validate(p); err == nil {
+			t.Errorf("%v: expected error", p)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.Local)
+
+	plans := []Plan{
+		{Days: "Mon-Fri", Time: "07:00", SoC: 80},
+		{Days: "Wed", Time: "20:00", SoC: 100},
+	}
+
+	ts, soc, err := Next(plans, now)
+	if err != nil || soc != 100 || !ts.Equal(time.Date(2021, 9, 1, 20, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected next %v at %d%%", ts, soc)
+	}
+
+	if _, _, err := Next(nil, now); err == nil {
+		t.Error("expected no occurrence")
+	}
+
+	if _, _, err := Next(append(plans, Plan{Days: "Foo", Time: "07:00", SoC: 80}), now); err == nil {
+		t.Error("expected invalid plan error")
+	}
+}
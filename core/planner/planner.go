@@ -0,0 +1,151 @@
+package planner
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/util"
+)
+
+// ErrNoPriceVariation indicates that rates don't vary before target time, i.e. planning has no benefit
+var ErrNoPriceVariation = errors.New("no price variation before target time")
+
+// Planner plans a series of charging slots for a given (variable) tariff
+type Planner struct {
+	log    *util.Logger
+	clock  clock.Clock
+	tariff api.TariffForecast
+}
+
+// New creates a price planner. Returns nil if the tariff does not provide a price forecast.
+func New(log *util.Logger, tariff api.Tariff) *Planner {
+	forecast, ok := tariff.(api.TariffForecast)
+	if !ok {
+		return nil
+	}
+
+	return &Planner{
+		log:    log,
+		clock:  clock.New(),
+		tariff: forecast,
+	}
+}
+
+// window clamps rates to the planning window
+func (t *Planner) window(rates api.Rates, targetTime time.Time) api.Rates {
+	now := t.clock.Now()
+
+	var window api.Rates
+	for _, r := range rates {
+		if !r.End.After(now) || !r.Start.Before(targetTime) {
+			continue
+		}
+
+		if r.Start.Before(now) {
+			r.Start = now
+		}
+		if r.End.After(targetTime) {
+			r.End = targetTime
+		}
+
+		window = append(window, r)
+	}
+
+	return window
+}
+
+// variable returns true if the rates differ in price
+func variable(rates api.Rates) bool {
+	for _, r := range rates {
+		if r.Price != rates[0].Price {
+			return true
+		}
+	}
+	return false
+}
+
+// plan selects the cheapest rates until the required duration is covered
+func (t *Planner) plan(rates api.Rates, requiredDuration time.Duration, targetTime time.Time) api.Rates {
+	window := t.window(rates, targetTime)
+
+	// cheapest first, prefer earlier slots at same price
+	sort.SliceStable(window, func(i, j int) bool {
+		if window[i].Price == window[j].Price {
+			return window[i].Start.Before(window[j].Start)
+		}
+		return window[i].Price < window[j].Price
+	})
+
+	var plan api.Rates
+	for _, r := range window {
+		if requiredDuration <= 0 {
+			break
+		}
+
+		// only use the required part of the slot, but finish as late as possible
+		if slot := r.End.Sub(r.Start); slot > requiredDuration {
+			r.Start = r.End.Add(-requiredDuration)
+		}
+
+		requiredDuration -= r.End.Sub(r.Start)
+		plan = append(plan, r)
+	}
+
+	sort.Slice(plan, func(i, j int) bool {
+		return plan[i].Start.Before(plan[j].Start)
+	})
+
+	return plan
+}
+
+// Plan creates the cheapest charging plan that covers the required duration before target time
+func (t *Planner) Plan(requiredDuration time.Duration, targetTime time.Time) (api.Rates, error) {
+	rates, err := t.tariff.Rates()
+	if err != nil {
+		return nil, err
+	}
+
+	if !variable(t.window(rates, targetTime)) {
+		return nil, ErrNoPriceVariation
+	}
+
+	plan := t.plan(rates, requiredDuration, targetTime)
+	if len(plan) == 0 && requiredDuration > 0 {
+		return nil, errors.New("no rates available before target time")
+	}
+
+	return plan, nil
+}
+
+// Active returns true if charging is required now to follow the cheapest plan.
+// If the plan cannot cover the required duration, charging is always active.
+func (t *Planner) Active(requiredDuration time.Duration, targetTime time.Time) (bool, api.Rates, error) {
+	plan, err := t.Plan(requiredDuration, targetTime)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var planned time.Duration
+	for _, r := range plan {
+		planned += r.End.Sub(r.Start)
+	}
+
+	// not enough time left for reaching target
+	if planned < requiredDuration {
+		t.log.DEBUG.Printf("planner: not enough time for %v charging, planned %v", requiredDuration.Round(time.Minute), planned.Round(time.Minute))
+		return true, plan, nil
+	}
+
+	now := t.clock.Now()
+	for _, r := range plan {
+		if !r.Start.After(now) && r.End.After(now) {
+			t.log.DEBUG.Printf("planner: active slot %v-%v at %.3f", r.Start.Round(time.Minute), r.End.Round(time.Minute), r.Price)
+			return true, plan, nil
+		}
+	}
+
+	return false, plan, nil
+}
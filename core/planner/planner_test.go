@@ -0,0 +1,129 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/util"
+)
+
+type tariff struct {
+	rates api.Rates
+}
+
+func (t *tariff) IsCheap() (bool, error) {
+	return false, nil
+}
+
+func (t *tariff) CurrentPrice() (float64, error) {
+	return 0, nil
+}
+
+func (t *tariff) Rates() (api.Rates, error) {
+	return t.rates, nil
+}
+
+func rates(start time.Time, prices ...float64) api.Rates {
+	res := make(api.Rates, 0, len(prices))
+	for i, p := range prices {
+		slotStart := start.Add(time.Duration(i) * time.Hour)
+		res = append(res, api.Rate{
+			Start: slotStart,
+			End:   slotStart.Add(time.Hour),
+			Price: p,
+		})
+	}
+	return res
+}
+
+func TestPlannerNoForecast(t *testing.T) {
+	if p := New(util.NewLogger("foo"), nil); p != nil {
+		t.Error("expected nil planner")
+	}
+}
+
+func TestPlanner(t *testing.T) {
+	clck := clock.NewMock()
+	now := clck.Now()
+
+	p := New(util.NewLogger("foo"), &tariff{rates(now, 5, 3, 1, 2, 4)})
+	p.clock = clck
+
+	tc := []struct {
+		title    string
+		duration time.Duration
+		target   time.Time
+		active   bool
+		slots    int
+	}{
+		{"cheapest slot later", time.Hour, now.Add(5 * time.Hour), false, 1},
+		{"two cheapest slots", 2 * time.Hour, now.Add(5 * time.Hour), false, 2},
+		{"target excludes cheap slots", time.Hour, now.Add(2 * time.Hour), false, 1},
+		{"all slots required", 5 * time.Hour, now.Add(5 * time.Hour), true, 5},
+		{"not enough time", 3 * time.Hour, now.Add(2 * time.Hour), true, 2},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		active, plan, err := p.Active(tc.duration, tc.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if active != tc.active {
+			t.Errorf("expected active %v, got %v", tc.active, active)
+		}
+
+		if len(plan) != tc.slots {
+			t.Errorf("expected %d slots, got %d: %v", tc.slots, len(plan), plan)
+		}
+
+		for i := 1; i < len(plan); i++ {
+			if plan[i].Start.Before(plan[i-1].Start) {
+				t.Errorf("plan not ordered: %v", plan)
+			}
+		}
+	}
+}
+
+func TestPlannerPartialSlot(t *testing.T) {
+	clck := clock.NewMock()
+	now := clck.Now()
+
+	p := New(util.NewLogger("foo"), &tariff{rates(now, 1, 2)})
+	p.clock = clck
+
+	plan, err := p.Plan(30*time.Minute, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan) != 1 {
+		t.Fatalf("expected single slot, got %v", plan)
+	}
+
+	// partial slot finishes at end of cheapest hour
+	if !plan[0].Start.Equal(now.Add(30*time.Minute)) || !plan[0].End.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected slot %v", plan[0])
+	}
+}
+
+func TestPlannerFlatRates(t *testing.T) {
+	clck := clock.NewMock()
+	now := clck.Now()
+
+	p := New(util.NewLogger("foo"), &tariff{rates(now, 2, 2, 2, 1)})
+	p.clock = clck
+
+	if _, err := p.Plan(time.Hour, now.Add(3*time.Hour)); !errors.Is(err, ErrNoPriceVariation) {
+		t.Errorf("expected %v, got %v", ErrNoPriceVariation, err)
+	}
+
+	if _, err := p.Plan(time.Hour, now.Add(4*time.Hour)); err != nil {
+		t.Error(err)
+	}
+}
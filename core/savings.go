@@ -5,12 +5,17 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/core/store"
 	"github.com/evcc-io/evcc/tariff"
+	"github.com/evcc-io/evcc/util"
 )
 
 const DefaultGridPrice = 0.30
 const DefaultFeedInPrice = 0.08
 
+// savingsPersistInterval is the maximum interval between database writes
+const savingsPersistInterval = time.Minute
+
 // publisher gives access to the site's publish function
 type publisher interface {
 	publish(key string, val interface{})
@@ -18,30 +23,120 @@ type publisher interface {
 
 // Site is the main configuration container. A site can host multiple loadpoints.
 type Savings struct {
+	log                            *util.Logger
 	clock                          clock.Clock
+	store                          store.SavingsStore // optional persistence
+	pending                        store.Savings      // Savings not yet persisted
+	pendingDay                     time.Time          // Time the pending savings accrued
+	persisted                      time.Time          // Time of last database write
 	tariffs                        tariff.Tariffs
-	started                        time.Time // Boot time
-	updated                        time.Time // Time of last charged value update
-	gridCharged                    float64   // Grid energy charged since startup (kWh)
-	gridCost                       float64   // Running total of charged grid energy cost (e.g. EUR)
-	gridSavedCost                  float64   // Running total of saved cost from self consumption (e.g. EUR)
-	selfConsumptionCharged         float64   // Self-produced energy charged since startup (kWh)
-	selfConsumptionCost            float64   // Running total of charged self-produced energy cost (e.g. EUR)
-	lastGridPrice, lastFeedInPrice float64   // Stores the last published grid price. Needed to detect price changes (Awattar, ..)
+	started                        time.Time    // Boot time
+	updated                        time.Time    // Time of last charged value update
+	gridCharged                    float64      // Grid energy charged since startup (kWh)
+	gridCost                       float64      // Running total of charged grid energy cost (e.g. EUR)
+	gridSavedCost                  float64      // Running total of saved cost from self consumption (e.g. EUR)
+	selfConsumptionCharged         float64      // Self-produced energy charged since startup (kWh)
+	selfConsumptionCost            float64      // Running total of charged self-produced energy cost (e.g. EUR)
+	lastGridPrice, lastFeedInPrice float64      // Stores the last published grid price. Needed to detect price changes (Awattar, ..)
+	share                          float64      // Self-produced share of the last update
+	lastEnergy                     *meterEnergy // Last meter readings for energy-based accounting
+}
+
+// meterEnergy are the energy meter readings in kWh
+type meterEnergy struct {
+	grid, pv, charge float64
 }
 
-func NewSavings(tariffs tariff.Tariffs) *Savings {
+// NewSavings creates savings accounting. Store is optional and persists savings per day.
+func NewSavings(tariffs tariff.Tariffs, store store.SavingsStore) *Savings {
 	clock := clock.New()
 	savings := &Savings{
-		clock:   clock,
-		tariffs: tariffs,
-		started: clock.Now(),
-		updated: clock.Now(),
+		log:       util.NewLogger("savings"),
+		clock:     clock,
+		store:     store,
+		tariffs:   tariffs,
+		started:   clock.Now(),
+		updated:   clock.Now(),
+		persisted: clock.Now(),
+	}
+
+	if savings.store != nil {
+		savings.restore()
 	}
 
 	return savings
 }
 
+// restore loads the savings totals from database
+func (s *Savings) restore() {
+	since, err := s.store.SavingsSince(s.started)
+	if err != nil {
+		s.log.ERROR.Printf("restore: %v", err)
+		return
+	}
+
+	total, err := s.store.SavingsTotal()
+	if err != nil {
+		s.log.ERROR.Printf("restore: %v", err)
+		return
+	}
+
+	s.started = since
+	s.gridCharged = total.GridCharged
+	s.gridCost = total.GridCost
+	s.gridSavedCost = total.GridSavedCost
+	s.selfConsumptionCharged = total.SelfConsumptionCharged
+	s.selfConsumptionCost = total.SelfConsumptionCost
+}
+
+// sameDay checks if both times are on the same calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// persist writes pending savings to database if due or the day has changed
+func (s *Savings) persist(now time.Time) {
+	if s.pendingDay.IsZero() || now.Sub(s.persisted) < savingsPersistInterval && sameDay(now, s.pendingDay) {
+		return
+	}
+
+	s.Flush()
+}
+
+// Flush writes pending savings to database
+func (s *Savings) Flush() {
+	if s.store == nil || s.pendingDay.IsZero() {
+		return
+	}
+
+	if err := s.store.AddSavings(s.pendingDay, s.pending); err != nil {
+		s.log.ERROR.Printf("persist: %v", err)
+		return
+	}
+
+	s.pending = store.Savings{}
+	s.pendingDay = time.Time{}
+	s.persisted = s.clock.Now()
+}
+
+// totals returns the running totals
+func (s *Savings) totals() store.Savings {
+	return store.Savings{
+		GridCharged:            s.gridCharged,
+		GridCost:               s.gridCost,
+		GridSavedCost:          s.gridSavedCost,
+		SelfConsumptionCharged: s.selfConsumptionCharged,
+		SelfConsumptionCost:    s.selfConsumptionCost,
+	}
+}
+
+// energyMix returns the self-produced share of the last update and the current prices
+func (s *Savings) energyMix() (float64, float64, float64) {
+	return s.share, s.lastGridPrice, s.lastFeedInPrice
+}
+
 func (s *Savings) Since() time.Time {
 	return s.started
 }
@@ -127,6 +222,9 @@ func (s *Savings) Update(p publisher, gridPower, pvPower, batteryPower, chargePo
 	gridPrice, feedinPrice := s.updatePrices(p)
 	defer func() { s.updated = s.clock.Now() }()
 
+	// flush pending values on idle cycles
+	s.persist(s.clock.Now())
+
 	// no charging, no need to update
 	if chargePower == 0 {
 		return
@@ -135,7 +233,54 @@ func (s *Savings) Update(p publisher, gridPower, pvPower, batteryPower, chargePo
 	// assume charge power as constant over the duration -> rough kWh estimate
 	energyAdded := s.clock.Since(s.updated).Hours() * chargePower / 1e3
 	share := s.shareOfSelfProducedEnergy(gridPower, pvPower, batteryPower)
+	s.share = share
+
+	s.add(p, energyAdded, share, gridPrice, feedinPrice)
+}
+
+// UpdateEnergy updates savings from energy meter readings in kWh. Self-produced share is
+// derived from the average grid and pv power over the interval between readings.
+func (s *Savings) UpdateEnergy(p publisher, gridEnergy, pvEnergy, batteryPower, chargeEnergy float64) {
+	gridPrice, feedinPrice := s.updatePrices(p)
+
+	now := s.clock.Now()
+	last := s.lastEnergy
 
+	// flush pending values on idle cycles
+	s.persist(now)
+
+	s.lastEnergy = &meterEnergy{grid: gridEnergy, pv: pvEnergy, charge: chargeEnergy}
+	defer func() { s.updated = now }()
+
+	// first reading
+	if last == nil {
+		return
+	}
+
+	dGrid := gridEnergy - last.grid
+	dPV := pvEnergy - last.pv
+	dCharge := chargeEnergy - last.charge
+
+	// meter reset or replaced
+	if dGrid < 0 || dPV < 0 || dCharge < 0 {
+		return
+	}
+
+	hours := now.Sub(s.updated).Hours()
+
+	// no charging, no need to update
+	if dCharge == 0 || hours == 0 {
+		return
+	}
+
+	share := s.shareOfSelfProducedEnergy(1e3*dGrid/hours, 1e3*dPV/hours, batteryPower)
+	s.share = share
+
+	s.add(p, dCharge, share, gridPrice, feedinPrice)
+}
+
+// add accounts charged energy in kWh given the share of self-produced energy
+func (s *Savings) add(p publisher, energyAdded, share, gridPrice, feedinPrice float64) {
 	addedSelfConsumption := energyAdded * share
 	addedGrid := energyAdded - addedSelfConsumption
 
@@ -145,6 +290,26 @@ func (s *Savings) Update(p publisher, gridPower, pvPower, batteryPower, chargePo
 	s.selfConsumptionCharged += addedSelfConsumption
 	s.selfConsumptionCost += addedSelfConsumption * feedinPrice
 
+	// pending values must not span days
+	now := s.clock.Now()
+	if s.store != nil {
+		if !s.pendingDay.IsZero() && !sameDay(now, s.pendingDay) {
+			s.Flush()
+		}
+
+		s.pending.Add(store.Savings{
+			GridCharged:            addedGrid,
+			GridCost:               addedGrid * gridPrice,
+			GridSavedCost:          addedSelfConsumption * (gridPrice - feedinPrice),
+			SelfConsumptionCharged: addedSelfConsumption,
+			SelfConsumptionCost:    addedSelfConsumption * feedinPrice,
+		})
+
+		if s.pendingDay.IsZero() {
+			s.pendingDay = now
+		}
+	}
+
 	p.publish("savingsTotalCharged", s.TotalCharged())
 	p.publish("savingsGridCharged", s.gridCharged)
 	p.publish("savingsSelfConsumptionCharged", s.selfConsumptionCharged)
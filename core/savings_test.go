@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/core/store"
 )
 
 func assertEnergy(t *testing.T, s *Savings, total, self, percentage float64) {
@@ -224,3 +225,116 @@ func TestEffectiveEnergyPriceAndSavingsAmount(t *testing.T) {
 		assertPrices(t, s, tc.effectivePrice, tc.savingsAmount)
 	}
 }
+
+func TestSavingsWithEnergy(t *testing.T) {
+	p := StubPublisher{}
+
+	clck := clock.NewMock()
+	s := &Savings{
+		clock:   clck,
+		started: clck.Now(),
+		updated: clck.Now(),
+	}
+
+	tc := []struct {
+		title                   string
+		grid, pv, charge        float64 // meter readings
+		total, self, percentage float64
+	}{
+		{"initial reading",
+			100, 200, 300,
+			0, 0, 0},
+		{"half grid, half pv",
+			102.5, 202.5, 305,
+			5, 2.5, 50},
+		{"full pv",
+			102.5, 207.5, 310,
+			10, 7.5, 75},
+		{"not charging",
+			110, 207.5, 310,
+			10, 7.5, 75},
+		{"meter reset",
+			0, 0, 0,
+			10, 7.5, 75},
+		{"full grid after reset",
+			5, 0, 5,
+			15, 7.5, 50},
+	}
+
+	for _, tc := range tc {
+		t.Logf("%+v", tc)
+
+		clck.Add(time.Hour)
+		s.UpdateEnergy(p, tc.grid, tc.pv, 0, tc.charge)
+		assertEnergy(t, s, tc.total, tc.self, tc.percentage)
+	}
+}
+
+type savingsStore struct {
+	days map[string]store.Savings
+}
+
+func (s *savingsStore) AddSavings(day time.Time, delta store.Savings) error {
+	key := day.Format("2006-01-02")
+	res := s.days[key]
+	res.Add(delta)
+	s.days[key] = res
+	return nil
+}
+
+func (s *savingsStore) SavingsSince(ts time.Time) (time.Time, error) {
+	return ts, nil
+}
+
+func (s *savingsStore) SavingsTotal() (store.Savings, error) {
+	return store.Savings{}, nil
+}
+
+func TestSavingsPersist(t *testing.T) {
+	p := StubPublisher{}
+
+	clck := clock.NewMock()
+	clck.Set(time.Date(2021, 12, 31, 23, 50, 0, 0, time.Local))
+
+	db := &savingsStore{days: make(map[string]store.Savings)}
+	s := &Savings{
+		clock:     clck,
+		store:     db,
+		started:   clck.Now(),
+		updated:   clck.Now(),
+		persisted: clck.Now(),
+	}
+
+	// 1kWh charged before midnight
+	clck.Add(6 * time.Minute)
+	s.Update(p, 10000, 0, 0, 10000)
+
+	if len(db.days) != 0 {
+		t.Errorf("unexpected write before persist interval: %v", db.days)
+	}
+
+	// 1kWh charged after midnight flushes previous day
+	clck.Add(6 * time.Minute)
+	s.Update(p, 10000, 0, 0, 10000)
+
+	if res := db.days["2021-12-31"]; !compareWithTolerane(res.GridCharged, 1) {
+		t.Errorf("expected 1kWh on previous day, got %v", db.days)
+	}
+
+	// idle cycle flushes pending values
+	clck.Add(time.Minute)
+	s.Update(p, 0, 0, 0, 0)
+
+	if res := db.days["2022-01-01"]; !compareWithTolerane(res.GridCharged, 1) {
+		t.Errorf("expected 1kWh on current day, got %v", db.days)
+	}
+
+	// flush on stop
+	clck.Add(6 * time.Second)
+	s.Update(p, 10000, 0, 0, 10000)
+	s.Flush()
+
+	if res := db.days["2022-01-01"]; !compareWithTolerane(res.GridCharged, 1+10000*6/3600/1e3) {
+		t.Errorf("expected flushed values on current day, got %v", db.days)
+	}
+}
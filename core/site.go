@@ -4,12 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/avast/retry-go/v3"
 	"github.com/evcc-io/evcc/api"
 	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/planner"
+	"github.com/evcc-io/evcc/core/store"
 	"github.com/evcc-io/evcc/push"
 	"github.com/evcc-io/evcc/tariff"
 	"github.com/evcc-io/evcc/util"
@@ -23,6 +26,7 @@ type Updater interface {
 // Site is the main configuration container. A site can host multiple loadpoints.
 type Site struct {
 	uiChan       chan<- util.Param // client push messages
+	pushChan     chan<- push.Event // notifications
 	lpUpdateChan chan *LoadPoint
 
 	*Health
@@ -37,6 +41,10 @@ type Site struct {
 	Meters        MetersConfig // Meter references
 	PrioritySoC   float64      `mapstructure:"prioritySoC"` // prefer battery up to this SoC
 	BufferSoC     float64      `mapstructure:"bufferSoC"`   // ignore battery above this SoC
+	MaxCurrent    float64      `mapstructure:"maxCurrent"`  // main fuse per-phase current limit
+
+	BatteryDischargeControl bool    `mapstructure:"batteryDischargeControl"` // prevent battery discharge while fast charging
+	BatteryGridChargeLimit  float64 `mapstructure:"batteryGridChargeLimit"`  // charge battery from grid at cheap tariff up to this SoC
 
 	// meters
 	gridMeter     api.Meter   // Grid usage meter
@@ -47,11 +55,22 @@ type Site struct {
 	loadpoints []*LoadPoint   // Loadpoints
 	savings    *Savings       // Savings
 
+	savingsEnergy bool // Savings accounted from energy meter readings
+
+	powerLimiter PowerLimiter // Loadpoints' total power limit
+
 	// cached state
-	gridPower       float64 // Grid power
-	pvPower         float64 // PV power
-	batteryPower    float64 // Battery charge power
-	batteryBuffered bool    // Battery buffer active
+	gridPower       float64         // Grid power
+	pvPower         float64         // PV power
+	batteryPower    float64         // Battery charge power
+	batteryBuffered bool            // Battery buffer active
+	batterySoC      float64         // Battery SoC
+	batteryMode     api.BatteryMode // Battery operation mode
+	gridCurrents    []float64       // Grid phase currents
+	powerLimited    bool            // Loadpoints' total power limit active
+	meterErrors     map[string]bool // Failing meters
+
+	gridRates, feedInRates api.Rates // Tariff price forecasts
 }
 
 // MetersConfig contains the loadpoint's meter configuration
@@ -70,6 +89,7 @@ func NewSiteFromConfig(
 	other map[string]interface{},
 	loadpoints []*LoadPoint,
 	tariffs tariff.Tariffs,
+	persistence store.Store,
 ) (*Site, error) {
 	site := NewSite()
 	if err := util.DecodeOther(other, &site); err != nil {
@@ -79,7 +99,20 @@ func NewSiteFromConfig(
 	Voltage = site.Voltage
 	site.loadpoints = loadpoints
 	site.tariffs = tariffs
-	site.savings = NewSavings(tariffs)
+	site.savings = NewSavings(tariffs, persistence)
+
+	for _, lp := range loadpoints {
+		// allow sessions to account solar share and price
+		lp.savings = site.savings
+		lp.sessionStore = persistence
+
+		// use price-optimised target charging for dynamic tariffs, flat rates fall back to linear timer
+		if tariffs.Grid != nil {
+			if p := planner.New(lp.log, tariffs.Grid); p != nil {
+				lp.socTimer.SetPlanner(p)
+			}
+		}
+	}
 
 	if site.Meters.GridMeterRef != "" {
 		site.gridMeter = cp.Meter(site.Meters.GridMeterRef)
@@ -120,6 +153,18 @@ func NewSiteFromConfig(
 		return nil, errors.New("missing either grid or pv meter")
 	}
 
+	// load management requires phase currents
+	if _, ok := site.gridMeter.(api.MeterCurrent); site.MaxCurrent > 0 && !ok {
+		return nil, errors.New("maxCurrent requires grid meter with currents")
+	}
+
+	// battery control requires controllable battery
+	if site.batteryControl() && len(site.batteryControllers()) == 0 {
+		site.log.WARN.Println("battery control requires battery meter with batterymode")
+	}
+
+	site.savingsEnergy = site.energyMetered()
+
 	return site, nil
 }
 
@@ -237,6 +282,36 @@ func (site *Site) publish(key string, val interface{}) {
 	}
 }
 
+// pushEvent sends push messages to clients
+func (site *Site) pushEvent(event string) {
+	if site.pushChan != nil {
+		site.pushChan <- push.Event{Event: event}
+	}
+}
+
+// setMeterError publishes the meter's error and sends a notification when it starts failing
+func (site *Site) setMeterError(name string, err error) {
+	failing := err != nil
+	if site.meterErrors[name] == failing {
+		return
+	}
+
+	if site.meterErrors == nil {
+		site.meterErrors = make(map[string]bool)
+	}
+	site.meterErrors[name] = failing
+
+	var msg string
+	if failing {
+		msg = err.Error()
+	}
+	site.publish(name+"MeterError", msg)
+
+	if failing {
+		site.pushEvent(evMeterError)
+	}
+}
+
 // updateMeter updates and publishes single meter
 func (site *Site) updateMeter(meter api.Meter, power *float64) func() error {
 	return func() error {
@@ -256,12 +331,14 @@ func (site *Site) updateMeters() error {
 			return nil
 		}
 
-		err := retry.Do(site.updateMeter(meter, power), retryOptions...)
+		err := retry.Do(site.updateMeter(meter, power), retryOptionsWithMetrics(name, "")...)
+		site.setMeterError(name, err)
 
 		if err == nil {
 			site.log.DEBUG.Printf("%s power: %.0fW", name, *power)
 			site.publish(name+"Power", *power)
 		} else {
+			countError(name, "")
 			err = fmt.Errorf("updating %s meter: %v", name, err)
 			site.log.ERROR.Println(err)
 		}
@@ -274,9 +351,10 @@ func (site *Site) updateMeters() error {
 	if len(site.pvMeters) > 0 {
 		site.pvPower = 0
 
+		var pvErr error
 		for id, meter := range site.pvMeters {
 			var power float64
-			err := retry.Do(site.updateMeter(meter, &power), retryOptions...)
+			err := retry.Do(site.updateMeter(meter, &power), retryOptionsWithMetrics("pv", "")...)
 
 			if err == nil {
 				site.pvPower += power
@@ -284,10 +362,13 @@ func (site *Site) updateMeters() error {
 					site.log.WARN.Printf("pv %d power: %.0fW is negative - check configuration if sign is correct", id, power)
 				}
 			} else {
+				countError("pv", "")
 				err = fmt.Errorf("updating pv meter %d: %v", id, err)
 				site.log.ERROR.Println(err)
+				pvErr = err
 			}
 		}
+		site.setMeterError("pv", pvErr)
 
 		site.log.DEBUG.Printf("pv power: %.0fW", site.pvPower)
 		site.publish("pvPower", site.pvPower)
@@ -296,27 +377,33 @@ func (site *Site) updateMeters() error {
 	if len(site.batteryMeters) > 0 {
 		site.batteryPower = 0
 
+		var batteryErr error
 		for id, meter := range site.batteryMeters {
 			var power float64
-			err := retry.Do(site.updateMeter(meter, &power), retryOptions...)
+			err := retry.Do(site.updateMeter(meter, &power), retryOptionsWithMetrics("battery", "")...)
 
 			if err == nil {
 				site.batteryPower += power
 			} else {
-				site.log.ERROR.Println(fmt.Errorf("updating battery meter %d: %v", id, err))
+				countError("battery", "")
+				batteryErr = fmt.Errorf("updating battery meter %d: %v", id, err)
+				site.log.ERROR.Println(batteryErr)
 			}
 		}
+		site.setMeterError("battery", batteryErr)
 
 		site.log.DEBUG.Printf("battery power: %.0fW", site.batteryPower)
 		site.publish("batteryPower", site.batteryPower)
 	}
 
 	// currents
+	site.gridCurrents = nil
 	if phaseMeter, ok := site.gridMeter.(api.MeterCurrent); err == nil && ok {
 		i1, i2, i3, err := phaseMeter.Currents()
 		if err == nil {
-			site.log.DEBUG.Printf("grid currents: %.3gA", []float64{i1, i2, i3})
-			site.publish("gridCurrents", []float64{i1, i2, i3})
+			site.gridCurrents = []float64{i1, i2, i3}
+			site.log.DEBUG.Printf("grid currents: %.3gA", site.gridCurrents)
+			site.publish("gridCurrents", site.gridCurrents)
 		} else {
 			site.log.ERROR.Println(fmt.Errorf("updating grid meter currents: %v", err))
 		}
@@ -344,6 +431,71 @@ func (site *Site) updateMeters() error {
 	return err
 }
 
+// energyMetered checks if grid, pv and charge meters all provide energy readings
+func (site *Site) energyMetered() bool {
+	if _, ok := site.gridMeter.(api.MeterEnergy); !ok {
+		return false
+	}
+
+	for _, meter := range site.pvMeters {
+		if _, ok := meter.(api.MeterEnergy); !ok {
+			return false
+		}
+	}
+
+	for _, lp := range site.loadpoints {
+		if _, ok := lp.chargeMeter.(api.MeterEnergy); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// meterEnergies returns the total grid, pv and charge energy readings in kWh
+func (site *Site) meterEnergies() (float64, float64, float64, error) {
+	grid, err := site.gridMeter.(api.MeterEnergy).TotalEnergy()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("grid meter energy: %w", err)
+	}
+
+	var pv float64
+	for id, meter := range site.pvMeters {
+		f, err := meter.(api.MeterEnergy).TotalEnergy()
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("pv meter %d energy: %w", id, err)
+		}
+		pv += f
+	}
+
+	var charge float64
+	for id, lp := range site.loadpoints {
+		f, err := lp.chargeMeter.(api.MeterEnergy).TotalEnergy()
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("charge meter %d energy: %w", id+1, err)
+		}
+		charge += f
+	}
+
+	return grid, pv, charge, nil
+}
+
+// updateSavings updates savings using energy readings if available or power values otherwise
+func (site *Site) updateSavings(totalChargePower float64) {
+	if site.savingsEnergy {
+		grid, pv, charge, err := site.meterEnergies()
+		if err != nil {
+			site.log.ERROR.Printf("savings: %v", err)
+			return
+		}
+
+		site.savings.UpdateEnergy(site, grid, pv, site.batteryPower, charge)
+		return
+	}
+
+	site.savings.Update(site, site.gridPower, site.pvPower, site.batteryPower, totalChargePower)
+}
+
 // sitePower returns the net power exported by the site minus a residual margin.
 // negative values mean grid: export, battery: charging
 func (site *Site) sitePower() (float64, error) {
@@ -366,6 +518,7 @@ func (site *Site) sitePower() (float64, error) {
 				socs += soc / float64(len(site.batteryMeters))
 			}
 		}
+		site.batterySoC = socs
 		site.publish("batterySoC", math.Trunc(socs))
 
 		site.Lock()
@@ -387,6 +540,30 @@ func (site *Site) sitePower() (float64, error) {
 	return sitePower, nil
 }
 
+// updateTariffRates publishes tariff price forecasts if changed
+func (site *Site) updateTariffRates() {
+	update := func(name string, t api.Tariff, rates *api.Rates) {
+		forecast, ok := t.(api.TariffForecast)
+		if !ok {
+			return
+		}
+
+		res, err := forecast.Rates()
+		if err != nil {
+			site.log.ERROR.Printf("%s tariff rates: %v", name, err)
+			return
+		}
+
+		if !reflect.DeepEqual(res, *rates) {
+			*rates = res
+			site.publish(name+"Rates", res)
+		}
+	}
+
+	update("tariffGrid", site.tariffs.Grid, &site.gridRates)
+	update("tariffFeedIn", site.tariffs.FeedIn, &site.feedInRates)
+}
+
 func (site *Site) update(lp Updater) {
 	site.log.DEBUG.Println("----")
 
@@ -405,8 +582,19 @@ func (site *Site) update(lp Updater) {
 	}
 
 	if sitePower, err := site.sitePower(); err == nil {
+		site.manageCurrent()
+
+		// hand surplus to loadpoints by priority
+		for l, power := range site.allocateSurplus(sitePower) {
+			if Updater(l) == lp {
+				sitePower = power
+			}
+		}
+
 		lp.Update(sitePower, cheap, site.batteryBuffered)
 
+		site.updateBatteryMode(cheap)
+
 		// ignore negative pvPower values as that means it is not an energy source but consumption
 		homePower := site.gridPower + math.Max(0, site.pvPower) + site.batteryPower - totalChargePower
 		homePower = math.Max(homePower, 0)
@@ -415,9 +603,14 @@ func (site *Site) update(lp Updater) {
 		site.Health.Update()
 	}
 
-	// update savings
-	// TODO: use energy instead of current power for better results
-	site.savings.Update(site, site.gridPower, site.pvPower, site.batteryPower, totalChargePower)
+	site.updateTariffRates()
+
+	site.updateSavings(totalChargePower)
+
+	// account sessions with the updated energy mix
+	for _, lp := range site.loadpoints {
+		lp.updateSession()
+	}
 }
 
 // prepare publishes initial values
@@ -428,14 +621,22 @@ func (site *Site) prepare() {
 	site.publish("pvConfigured", len(site.pvMeters) > 0)
 	site.publish("batteryConfigured", len(site.batteryMeters) > 0)
 	site.publish("prioritySoC", site.PrioritySoC)
+	site.publish("maxCurrent", site.MaxCurrent)
 
 	site.publish("currency", site.tariffs.Currency.String())
 	site.publish("savingsSince", site.savings.Since().Unix())
+
+	accuracy := "power"
+	if site.savingsEnergy {
+		accuracy = "energy"
+	}
+	site.publish("savingsAccuracy", accuracy)
 }
 
 // Prepare attaches communication channels to site and loadpoints
 func (site *Site) Prepare(uiChan chan<- util.Param, pushChan chan<- push.Event) {
 	site.uiChan = uiChan
+	site.pushChan = pushChan
 	site.lpUpdateChan = make(chan *LoadPoint, 1) // 1 capacity to avoid deadlock
 
 	site.prepare()
@@ -487,6 +688,8 @@ func (site *Site) Run(stopC chan struct{}, interval time.Duration) {
 		case lp := <-site.lpUpdateChan:
 			site.update(lp)
 		case <-stopC:
+			site.resetBatteryMode()
+			site.savings.Flush()
 			return
 		}
 	}
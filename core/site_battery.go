@@ -0,0 +1,86 @@
+package core
+
+import (
+	"github.com/evcc-io/evcc/api"
+)
+
+// batteryControllers returns the controllable battery meters
+func (site *Site) batteryControllers() []api.BatteryController {
+	var res []api.BatteryController
+	for _, meter := range site.batteryMeters {
+		if bc, ok := meter.(api.BatteryController); ok {
+			res = append(res, bc)
+		}
+	}
+	return res
+}
+
+// batteryControl checks if any battery control option is configured
+func (site *Site) batteryControl() bool {
+	return site.BatteryDischargeControl || site.BatteryGridChargeLimit > 0
+}
+
+// fastCharging checks if any loadpoint is charging in now mode
+func (site *Site) fastCharging() bool {
+	for _, lp := range site.loadpoints {
+		if lp.GetMode() == api.ModeNow && lp.charging() {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredBatteryMode determines the battery mode according to tariff and charging state
+func (site *Site) requiredBatteryMode(cheap bool) api.BatteryMode {
+	switch {
+	case cheap && site.batterySoC < site.BatteryGridChargeLimit:
+		return api.BatteryCharge
+	case site.BatteryDischargeControl && site.fastCharging():
+		return api.BatteryHold
+	default:
+		return api.BatteryNormal
+	}
+}
+
+// updateBatteryMode applies the required battery mode to all controllable batteries
+func (site *Site) updateBatteryMode(cheap bool) {
+	if !site.batteryControl() {
+		return
+	}
+
+	controllers := site.batteryControllers()
+	if len(controllers) == 0 {
+		return
+	}
+
+	mode := site.requiredBatteryMode(cheap)
+	if mode == site.batteryMode {
+		return
+	}
+
+	site.log.DEBUG.Printf("set battery mode: %s", mode)
+
+	for id, bc := range controllers {
+		if err := bc.SetBatteryMode(mode); err != nil {
+			// retry on next cycle
+			site.log.ERROR.Printf("battery %d mode: %v", id, err)
+			return
+		}
+	}
+
+	site.batteryMode = mode
+	site.publish("batteryMode", mode)
+}
+
+// resetBatteryMode returns controllable batteries to normal operation
+func (site *Site) resetBatteryMode() {
+	if site.batteryMode == api.BatteryUnknown || site.batteryMode == api.BatteryNormal {
+		return
+	}
+
+	for id, bc := range site.batteryControllers() {
+		if err := bc.SetBatteryMode(api.BatteryNormal); err != nil {
+			site.log.ERROR.Printf("battery %d mode: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/util"
+)
+
+type batteryController struct {
+	api.Meter
+	mode api.BatteryMode
+}
+
+func (b *batteryController) SetBatteryMode(mode api.BatteryMode) error {
+	b.mode = mode
+	return nil
+}
+
+func TestUpdateBatteryMode(t *testing.T) {
+	tc := []struct {
+		title       string
+		control     bool
+		cheap       bool
+		soc         float64
+		mode        api.ChargeMode
+		status      api.ChargeStatus
+		batteryMode api.BatteryMode
+	}{
+		{"normal", true, false, 50, api.ModePV, api.StatusC, api.BatteryNormal},
+		{"fast charging", true, false, 50, api.ModeNow, api.StatusC, api.BatteryHold},
+		{"fast charging not charging", true, false, 50, api.ModeNow, api.StatusB, api.BatteryNormal},
+		{"cheap", true, true, 50, api.ModePV, api.StatusB, api.BatteryCharge},
+		{"cheap above limit", true, true, 90, api.ModeNow, api.StatusC, api.BatteryHold},
+		{"control disabled", false, true, 50, api.ModeNow, api.StatusC, api.BatteryUnknown},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		battery := &batteryController{}
+
+		site := &Site{
+			log:           util.NewLogger("foo"),
+			batteryMeters: []api.Meter{battery},
+			batterySoC:    tc.soc,
+			loadpoints: []*LoadPoint{{
+				log:    util.NewLogger("foo"),
+				Mode:   tc.mode,
+				status: tc.status,
+			}},
+		}
+
+		if tc.control {
+			site.BatteryDischargeControl = true
+			site.BatteryGridChargeLimit = 80
+		}
+
+		site.updateBatteryMode(tc.cheap)
+
+		if battery.mode != tc.batteryMode {
+			t.Errorf("expected %s, got %s", tc.batteryMode, battery.mode)
+		}
+	}
+}
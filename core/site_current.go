@@ -0,0 +1,238 @@
+package core
+
+import (
+	"math"
+	"sort"
+
+	"github.com/evcc-io/evcc/api"
+)
+
+// currentDemand is a loadpoint's current range for load management
+type currentDemand struct {
+	min, max float64
+}
+
+// distributeCurrent distributes the available per-phase current across loadpoints.
+// Each loadpoint is first assigned its minimum current in order. The remainder is then
+// shared equally up to each loadpoint's maximum current.
+func distributeCurrent(available float64, demands []currentDemand) []float64 {
+	res := make([]float64, len(demands))
+
+	var active []int
+	for i, d := range demands {
+		if d.min <= available {
+			res[i] = d.min
+			available -= d.min
+			active = append(active, i)
+		}
+	}
+
+	// fill up smallest remaining demand first to share remainder equally
+	sort.SliceStable(active, func(i, j int) bool {
+		return demands[active[i]].max-res[active[i]] < demands[active[j]].max-res[active[j]]
+	})
+
+	for n, i := range active {
+		share := available / float64(len(active)-n)
+		add := math.Min(share, demands[i].max-res[i])
+		res[i] += add
+		available -= add
+	}
+
+	return res
+}
+
+// loadpointCurrent returns the loadpoint's per-phase current as measured or estimated from power
+func (site *Site) loadpointCurrent(lp *LoadPoint) float64 {
+	if lp.chargeCurrents != nil {
+		return math.Max(lp.chargeCurrents[0], math.Max(lp.chargeCurrents[1], lp.chargeCurrents[2]))
+	}
+
+	if lp.activePhases == 0 {
+		return 0
+	}
+
+	return math.Max(0, powerToCurrent(lp.GetChargePower(), lp.activePhases))
+}
+
+// PowerLimiter provides an externally commanded limit for the loadpoints' total power, e.g. by the grid operator
+type PowerLimiter interface {
+	PowerLimit() (float64, bool)
+}
+
+// SetPowerLimiter sets the source of the loadpoints' total power limit
+func (site *Site) SetPowerLimiter(limiter PowerLimiter) {
+	site.powerLimiter = limiter
+}
+
+// loadpointPhases returns the loadpoint's active phases, assuming 3 phases if unknown
+func loadpointPhases(lp *LoadPoint) int {
+	if lp.activePhases == 0 {
+		return 3
+	}
+	return lp.activePhases
+}
+
+// distributePower distributes the available total power across loadpoints.
+// Returns the resulting per-phase currents.
+func distributePower(available float64, demands []currentDemand, phases []int) []float64 {
+	powerDemands := make([]currentDemand, len(demands))
+	for i, d := range demands {
+		factor := Voltage * float64(phases[i])
+		powerDemands[i] = currentDemand{min: d.min * factor, max: d.max * factor}
+	}
+
+	res := distributeCurrent(available, powerDemands)
+	for i := range res {
+		res[i] /= Voltage * float64(phases[i])
+	}
+
+	return res
+}
+
+// powerLimit returns the active total power limit and publishes it
+func (site *Site) powerLimit() (float64, bool) {
+	if site.powerLimiter == nil {
+		return 0, false
+	}
+
+	limit, active := site.powerLimiter.PowerLimit()
+	if !active {
+		limit = 0
+	}
+
+	if active != site.powerLimited {
+		if active {
+			site.log.WARN.Printf("power limit active: %.0fW", limit)
+		} else {
+			site.log.WARN.Println("power limit released")
+		}
+	}
+
+	site.publish("powerLimitActive", active)
+	site.publish("powerLimit", limit)
+
+	return limit, active
+}
+
+// availableCurrent returns the per-phase current available to all loadpoints below the site's maximum current
+func (site *Site) availableCurrent() float64 {
+	// grid current without loadpoints
+	// grid currents are unsigned and treated as import, single phases may import during total export
+	var maxGrid float64
+	for _, i := range site.gridCurrents {
+		maxGrid = math.Max(maxGrid, i)
+	}
+
+	available := site.MaxCurrent - maxGrid
+	for _, lp := range site.loadpoints {
+		available += site.loadpointCurrent(lp)
+	}
+
+	site.log.DEBUG.Printf("available current: %.3gA", available)
+	site.publish("availableCurrent", math.Max(available, 0))
+
+	return available
+}
+
+// waitingForCurrent checks if the loadpoint is disabled but may start charging
+func (lp *LoadPoint) waitingForCurrent() bool {
+	return lp.connected() && !lp.enabled && lp.GetMode() != api.ModeOff && !lp.targetSocReached()
+}
+
+// manageCurrent limits the loadpoints' charge currents to stay below the site's maximum per-phase current
+// and the loadpoints' total power limit
+func (site *Site) manageCurrent() {
+	currentLimited := site.MaxCurrent > 0 && site.gridCurrents != nil
+	powerLimit, powerLimited := site.powerLimit()
+
+	// release loadpoints once power limit ends
+	if !currentLimited && !powerLimited {
+		if site.powerLimited {
+			for _, lp := range site.loadpoints {
+				lp.resetSiteCurrentLimit()
+			}
+		}
+
+		site.powerLimited = false
+		return
+	}
+
+	site.powerLimited = powerLimited
+
+	// only charging loadpoints share the available current, loadpoints waiting to start reserve their minimum current
+	var demands []currentDemand
+	var phases []int
+	var loadpoints []*LoadPoint
+
+	for _, lp := range site.loadpoints {
+		switch {
+		case lp.charging():
+			demands = append(demands, currentDemand{min: lp.GetMinCurrent(), max: lp.GetMaxCurrent()})
+		case lp.waitingForCurrent():
+			demands = append(demands, currentDemand{min: lp.GetMinCurrent(), max: lp.GetMinCurrent()})
+		default:
+			continue
+		}
+
+		phases = append(phases, loadpointPhases(lp))
+		loadpoints = append(loadpoints, lp)
+	}
+
+	limits := make([]float64, len(demands))
+	for i, d := range demands {
+		limits[i] = d.max
+	}
+
+	// remaining headroom for idle loadpoints
+	leftoverCurrent := math.Inf(1)
+	leftoverPower := math.Inf(1)
+
+	if currentLimited {
+		available := site.availableCurrent()
+		for i, limit := range distributeCurrent(available, demands) {
+			limits[i] = math.Min(limits[i], limit)
+		}
+
+		leftoverCurrent = available
+		for _, limit := range limits {
+			leftoverCurrent -= limit
+		}
+	}
+
+	if powerLimited {
+		for i, limit := range distributePower(powerLimit, demands, phases) {
+			limits[i] = math.Min(limits[i], limit)
+		}
+
+		leftoverPower = powerLimit
+		for i, limit := range limits {
+			leftoverPower -= limit * Voltage * float64(phases[i])
+		}
+	}
+
+	for _, lp := range site.loadpoints {
+		// disconnected loadpoints must not start charging before next distribution
+		var limit float64
+
+		var demanding bool
+		for i, active := range loadpoints {
+			if lp == active {
+				limit = limits[i]
+				demanding = true
+			}
+		}
+
+		// idle loadpoints don't draw current but may start any time, they only get the remaining headroom
+		if !demanding && lp.connected() {
+			limit = math.Min(leftoverCurrent, leftoverPower/(Voltage*float64(loadpointPhases(lp))))
+			limit = math.Min(limit, lp.GetMaxCurrent())
+
+			if limit < lp.GetMinCurrent() {
+				limit = 0
+			}
+		}
+
+		lp.setSiteCurrentLimit(limit)
+	}
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	evbus "github.com/asaskevich/EventBus"
+	"github.com/benbjohnson/clock"
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/mock"
+	"github.com/evcc-io/evcc/util"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDistributeCurrent(t *testing.T) {
+	tc := []struct {
+		title     string
+		available float64
+		demands   []currentDemand
+		res       []float64
+	}{
+		{"single unlimited", 32, []currentDemand{{6, 16}}, []float64{16}},
+		{"single limited", 10, []currentDemand{{6, 16}}, []float64{10}},
+		{"single below min", 5, []currentDemand{{6, 16}}, []float64{0}},
+		{"two equal", 20, []currentDemand{{6, 16}, {6, 16}}, []float64{10, 10}},
+		{"two unequal max", 28, []currentDemand{{6, 10}, {6, 32}}, []float64{10, 18}},
+		{"second below min", 10, []currentDemand{{6, 16}, {6, 16}}, []float64{10, 0}},
+		{"none", 0, []currentDemand{{6, 16}, {6, 16}}, []float64{0, 0}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		res := distributeCurrent(tc.available, tc.demands)
+		for i := range res {
+			if res[i] != tc.res[i] {
+				t.Errorf("expected %v, got %v", tc.res, res)
+				break
+			}
+		}
+	}
+}
+
+func TestDistributePower(t *testing.T) {
+	Voltage = 230 // V
+
+	tc := []struct {
+		title     string
+		available float64
+		demands   []currentDemand
+		phases    []int
+		res       []float64
+	}{
+		{"single unlimited", 22080, []currentDemand{{6, 16}}, []int{3}, []float64{16}},
+		{"single limited", 6900, []currentDemand{{6, 16}}, []int{3}, []float64{10}},
+		{"single below min", 4000, []currentDemand{{6, 16}}, []int{3}, []float64{0}},
+		{"mixed phases", 9660, []currentDemand{{6, 16}, {6, 16}}, []int{3, 1}, []float64{9, 15}},
+		{"none", 0, []currentDemand{{6, 16}, {6, 16}}, []int{3, 3}, []float64{0, 0}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		res := distributePower(tc.available, tc.demands, tc.phases)
+		for i := range res {
+			if math.Abs(res[i]-tc.res[i]) > 1e-6 {
+				t.Errorf("expected %v, got %v", tc.res, res)
+				break
+			}
+		}
+	}
+}
+
+func TestManageCurrentIdleLoadpoint(t *testing.T) {
+	Voltage = 230 // V
+
+	ctrl := gomock.NewController(t)
+
+	newLoadpoint := func(status api.ChargeStatus, current float64) *LoadPoint {
+		return &LoadPoint{
+			log:            util.NewLogger("foo"),
+			bus:            evbus.New(),
+			clock:          clock.NewMock(),
+			charger:        mock.NewMockCharger(ctrl),
+			status:         status,
+			enabled:        true,
+			Mode:           api.ModeNow,
+			MinCurrent:     6,
+			MaxCurrent:     16,
+			chargeCurrent:  math.Max(current, 6),
+			chargeCurrents: []float64{current, current, current},
+		}
+	}
+
+	charging := newLoadpoint(api.StatusC, 16)
+	idle := newLoadpoint(api.StatusB, 0)
+
+	site := NewSite()
+	site.MaxCurrent = 20
+	site.gridCurrents = []float64{10, 10, 10}
+	site.loadpoints = []*LoadPoint{charging, idle}
+
+	site.manageCurrent()
+
+	// idle loadpoint doesn't reduce charging loadpoint's headroom
+	if charging.siteCurrentLimit != 16 {
+		t.Errorf("expected charging loadpoint limit 16A, got %.3gA", charging.siteCurrentLimit)
+	}
+
+	// idle loadpoint gets remaining headroom
+	if idle.siteCurrentLimit != 10 {
+		t.Errorf("expected idle loadpoint limit 10A, got %.3gA", idle.siteCurrentLimit)
+	}
+
+	// idle loadpoint is disabled if remaining headroom is below min current
+	site.gridCurrents = []float64{18, 18, 18}
+	idle.charger.(*mock.MockCharger).EXPECT().Enable(false).Return(nil)
+
+	site.manageCurrent()
+
+	if idle.siteCurrentLimit != 0 || idle.enabled {
+		t.Errorf("expected idle loadpoint disabled, got %.3gA (enabled: %t)", idle.siteCurrentLimit, idle.enabled)
+	}
+}
+
+func TestAvailableCurrent(t *testing.T) {
+	tc := []struct {
+		title        string
+		gridPower    float64
+		gridCurrents []float64
+		available    float64
+	}{
+		{"import", 3 * 2 * 230, []float64{2, 2, 2}, 34},
+		{"export treated as import", -3 * 2 * 230, []float64{2, 2, 2}, 34},
+		{"mixed import and export", -30 * 230, []float64{20, 25, 25}, 11},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		site := NewSite()
+		site.MaxCurrent = 20
+		site.gridPower = tc.gridPower
+		site.gridCurrents = tc.gridCurrents
+		site.loadpoints = []*LoadPoint{{
+			log:            util.NewLogger("foo"),
+			status:         api.StatusC,
+			chargeCurrents: []float64{16, 16, 16},
+		}}
+
+		if available := site.availableCurrent(); available != tc.available {
+			t.Errorf("expected %.3gA available, got %.3gA", tc.available, available)
+		}
+	}
+}
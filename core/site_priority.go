@@ -0,0 +1,101 @@
+package core
+
+import (
+	"math"
+	"sort"
+
+	"github.com/evcc-io/evcc/api"
+)
+
+// surplusDemand returns the power the loadpoint is able to take from the available surplus
+func (lp *LoadPoint) surplusDemand() float64 {
+	if !lp.connected() {
+		return 0
+	}
+
+	switch lp.GetMode() {
+	case api.ModeOff:
+		return 0
+	case api.ModePV, api.ModeMinPV:
+		// vehicle is full or has stopped drawing while enabled
+		if lp.targetSocReached() || lp.enabled && !lp.charging() {
+			return 0
+		}
+
+		phases := lp.activePhases
+		if phases == 0 {
+			phases = 3
+		}
+
+		// current consumption plus headroom up to max current
+		chargePower := lp.GetChargePower()
+		return chargePower + math.Max(0, Voltage*lp.GetMaxCurrent()*float64(phases)-chargePower)
+	default:
+		// loadpoint is not surplus-controlled, keep its current consumption
+		return lp.GetChargePower()
+	}
+}
+
+// sheddingLoadpoint returns the loadpoint that must shed load if the site imports beyond the loadpoints'
+// consumption. This is the lowest priority charging surplus-controlled loadpoint or the lowest priority loadpoint.
+func sheddingLoadpoint(loadpoints []*LoadPoint) *LoadPoint {
+	for i := len(loadpoints) - 1; i >= 0; i-- {
+		lp := loadpoints[i]
+		if mode := lp.GetMode(); (mode == api.ModePV || mode == api.ModeMinPV) && lp.charging() {
+			return lp
+		}
+	}
+
+	return loadpoints[len(loadpoints)-1]
+}
+
+// allocateSurplus distributes the site's available power to loadpoints by priority.
+// Higher priority loadpoints are served first, the remainder is passed on to the next loadpoint.
+// Returns the site power as seen by each loadpoint.
+func (site *Site) allocateSurplus(sitePower float64) map[*LoadPoint]float64 {
+	res := make(map[*LoadPoint]float64, len(site.loadpoints))
+
+	if len(site.loadpoints) < 2 {
+		for _, lp := range site.loadpoints {
+			res[lp] = sitePower
+		}
+		return res
+	}
+
+	// power available to all loadpoints including their current consumption
+	remaining := -sitePower
+	for _, lp := range site.loadpoints {
+		remaining += lp.GetChargePower()
+	}
+
+	loadpoints := make([]*LoadPoint, len(site.loadpoints))
+	copy(loadpoints, site.loadpoints)
+
+	sort.SliceStable(loadpoints, func(i, j int) bool {
+		return loadpoints[i].Priority > loadpoints[j].Priority
+	})
+
+	allocations := make(map[*LoadPoint]float64, len(loadpoints))
+	for _, lp := range loadpoints {
+		allocated := math.Max(0, math.Min(lp.surplusDemand(), remaining))
+		allocations[lp] = allocated
+		remaining -= allocated
+	}
+
+	// site import beyond the loadpoints' consumption is passed on as negative allocation
+	if remaining < 0 {
+		allocations[sheddingLoadpoint(loadpoints)] += remaining
+	}
+
+	for _, lp := range loadpoints {
+		allocated := allocations[lp]
+
+		lp.log.DEBUG.Printf("allocated power: %.0fW (priority %d)", allocated, lp.Priority)
+		lp.publish("allocatedPower", allocated)
+
+		// loadpoint sees its allocation as export
+		res[lp] = lp.GetChargePower() - allocated
+	}
+
+	return res
+}
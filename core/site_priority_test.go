@@ -0,0 +1,96 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/util"
+)
+
+func TestAllocateSurplus(t *testing.T) {
+	Voltage = 230 // V
+
+	newLoadpoint := func(priority int, mode api.ChargeMode, chargePower float64) *LoadPoint {
+		return &LoadPoint{
+			log:          util.NewLogger("foo"),
+			status:       api.StatusC,
+			Mode:         mode,
+			Priority:     priority,
+			MaxCurrent:   16,
+			activePhases: 1,
+			chargePower:  chargePower,
+		}
+	}
+
+	// target soc reached
+	full := func(lp *LoadPoint) *LoadPoint {
+		lp.vehicle = struct{ api.Vehicle }{}
+		lp.SoC.Target = 80
+		lp.vehicleSoc = 80
+		return lp
+	}
+
+	// enabled but not drawing
+	stopped := func(lp *LoadPoint) *LoadPoint {
+		lp.status = api.StatusB
+		lp.enabled = true
+		return lp
+	}
+
+	tc := []struct {
+		title     string
+		sitePower float64
+		lps       []*LoadPoint
+		res       []float64
+	}{
+		{"priority first", -3000, []*LoadPoint{
+			newLoadpoint(0, api.ModePV, 0),
+			newLoadpoint(1, api.ModePV, 0),
+		}, []float64{0, -3000}},
+		{"remainder to second", -5000, []*LoadPoint{
+			newLoadpoint(1, api.ModePV, 3000),
+			newLoadpoint(0, api.ModePV, 0),
+		}, []float64{-680, -3680}},
+		{"off not allocated", -5000, []*LoadPoint{
+			newLoadpoint(1, api.ModeOff, 0),
+			newLoadpoint(0, api.ModePV, 0),
+		}, []float64{0, -3680}},
+		{"now keeps consumption", 0, []*LoadPoint{
+			newLoadpoint(1, api.ModeNow, 3680),
+			newLoadpoint(0, api.ModePV, 1380),
+		}, []float64{0, 0}},
+		{"import passed to charging pv loadpoint", 2000, []*LoadPoint{
+			newLoadpoint(1, api.ModeOff, 0),
+			newLoadpoint(0, api.ModePV, 1000),
+		}, []float64{0, 2000}},
+		{"import passed to lowest priority", 3000, []*LoadPoint{
+			newLoadpoint(1, api.ModePV, 1000),
+			newLoadpoint(0, api.ModePV, 1000),
+		}, []float64{1000, 2000}},
+		{"full vehicle not allocated", -3000, []*LoadPoint{
+			full(newLoadpoint(1, api.ModePV, 0)),
+			newLoadpoint(0, api.ModePV, 0),
+		}, []float64{0, -3000}},
+		{"stopped vehicle not allocated", -3000, []*LoadPoint{
+			stopped(newLoadpoint(1, api.ModePV, 0)),
+			newLoadpoint(0, api.ModePV, 0),
+		}, []float64{0, -3000}},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		site := &Site{
+			log:        util.NewLogger("foo"),
+			loadpoints: tc.lps,
+		}
+
+		res := site.allocateSurplus(tc.sitePower)
+
+		for i, lp := range tc.lps {
+			if res[lp] != tc.res[i] {
+				t.Errorf("lp %d: expected site power %.0fW, got %.0fW", i, tc.res[i], res[lp])
+			}
+		}
+	}
+}
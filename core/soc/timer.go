@@ -1,10 +1,12 @@
 package soc
 
 import (
+	"errors"
 	"math"
 	"time"
 
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/planner"
 	"github.com/evcc-io/evcc/util"
 )
 
@@ -16,12 +18,15 @@ const (
 type Timer struct {
 	Adapter
 	log       *util.Logger
+	planner   *planner.Planner
 	current   float64
 	SoC       int
 	Time      time.Time
 	finishAt  time.Time
 	active    bool
+	planned   bool
 	validated bool
+	missed    bool
 }
 
 // NewTimer creates a Timer
@@ -34,6 +39,15 @@ func NewTimer(log *util.Logger, api Adapter) *Timer {
 	return lp
 }
 
+// SetPlanner enables price-optimised target charging using the given planner
+func (lp *Timer) SetPlanner(planner *planner.Planner) {
+	if lp == nil {
+		return
+	}
+
+	lp.planner = planner
+}
+
 // MustValidateDemand resets the flag for detecting if DemandActive has been called
 func (lp *Timer) MustValidateDemand() {
 	if lp == nil {
@@ -71,10 +85,34 @@ func (lp *Timer) Set(t time.Time) {
 		return
 	}
 
+	if !t.Equal(lp.Time) {
+		lp.setMissed(false)
+	}
+
 	lp.Time = t
 	lp.Publish("targetTime", lp.Time)
 }
 
+// Missed returns true if the target soc can't be reached in time even when charging at maximum power
+func (lp *Timer) Missed() bool {
+	if lp == nil {
+		return false
+	}
+
+	return lp.missed
+}
+
+func (lp *Timer) setMissed(missed bool) {
+	if lp.missed != missed {
+		lp.missed = missed
+		lp.Publish("targetTimeMissed", missed)
+
+		if missed {
+			lp.log.WARN.Printf("target charging: %d%% can't be reached until %v", lp.SoC, lp.Time.Round(time.Minute))
+		}
+	}
+}
+
 // Reset resets the target charging request
 func (lp *Timer) Reset() {
 	if lp == nil {
@@ -83,6 +121,39 @@ func (lp *Timer) Reset() {
 
 	lp.Set(time.Time{})
 	lp.Stop()
+
+	if lp.planner != nil {
+		lp.Publish("targetPlan", api.Rates(nil))
+	}
+}
+
+// plannerDemandActive uses the tariff planner to decide if charging is required in the current slot.
+// Returns false if no plan could be created.
+func (lp *Timer) plannerDemandActive(remainingDuration time.Duration) (bool, bool) {
+	active, plan, err := lp.planner.Active(remainingDuration, lp.Time)
+	if err != nil {
+		// flat rates are handled by the linear timer
+		if !errors.Is(err, planner.ErrNoPriceVariation) {
+			lp.log.WARN.Printf("target charging: planner: %v", err)
+		}
+		lp.Publish("targetPlan", api.Rates(nil))
+		return false, false
+	}
+
+	lp.Publish("targetPlan", plan)
+
+	if active != lp.active {
+		lp.active = active
+		lp.Publish("targetTimeActive", lp.active)
+
+		if active {
+			lp.log.INFO.Printf("target charging active for %v: planned slot (%v remaining)", lp.Time, remainingDuration.Round(time.Minute))
+		} else {
+			lp.log.DEBUG.Println("target charging: waiting for planned slot")
+		}
+	}
+
+	return lp.active, true
 }
 
 // DemandActive calculates remaining charge duration and returns true if charge start is required to achieve target soc in time
@@ -108,9 +179,23 @@ func (lp *Timer) DemandActive() bool {
 
 	// time
 	remainingDuration := time.Duration(float64(se.AssumedChargeDuration(lp.SoC, power)) / chargeEfficiency)
+
+	// target can't be reached even at maximum power
+	fastestDuration := time.Duration(float64(se.AssumedChargeDuration(lp.SoC, lp.GetMaxPower())) / chargeEfficiency)
+	lp.setMissed(fastestDuration > 0 && time.Now().Add(fastestDuration).After(lp.Time))
 	lp.finishAt = time.Now().Add(remainingDuration).Round(time.Minute)
 
 	lp.log.DEBUG.Printf("estimated charge duration: %v to %d%% at %.0fW", remainingDuration.Round(time.Minute), lp.SoC, power)
+
+	// use price-optimised plan if available, fall back to linear timer
+	lp.planned = false
+	if lp.planner != nil {
+		var active bool
+		if active, lp.planned = lp.plannerDemandActive(remainingDuration); lp.planned {
+			return active
+		}
+	}
+
 	if lp.active {
 		lp.log.DEBUG.Printf("projected end: %v", lp.finishAt)
 		lp.log.DEBUG.Printf("desired finish time: %v", lp.Time)
@@ -141,6 +226,12 @@ func (lp *Timer) DemandActive() bool {
 
 // Handle adjusts current up/down to achieve desired target time taking.
 func (lp *Timer) Handle() float64 {
+	// planned slots are charged at full power
+	if lp.planned {
+		lp.current = lp.GetMaxCurrent()
+		return lp.current
+	}
+
 	action := "steady"
 
 	switch {
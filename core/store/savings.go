@@ -0,0 +1,32 @@
+package store
+
+import "time"
+
+// Savings is the charged energy and cost accounting for a period
+type Savings struct {
+	Period                 string  `json:"period"`
+	GridCharged            float64 `json:"gridCharged"`            // kWh
+	GridCost               float64 `json:"gridCost"`               // e.g. EUR
+	GridSavedCost          float64 `json:"gridSavedCost"`          // e.g. EUR
+	SelfConsumptionCharged float64 `json:"selfConsumptionCharged"` // kWh
+	SelfConsumptionCost    float64 `json:"selfConsumptionCost"`    // e.g. EUR
+}
+
+// Add adds the values of other to s
+func (s *Savings) Add(other Savings) {
+	s.GridCharged += other.GridCharged
+	s.GridCost += other.GridCost
+	s.GridSavedCost += other.GridSavedCost
+	s.SelfConsumptionCharged += other.SelfConsumptionCharged
+	s.SelfConsumptionCost += other.SelfConsumptionCost
+}
+
+// SavingsStore persists savings per day
+type SavingsStore interface {
+	// AddSavings adds the values to the given day's savings record
+	AddSavings(day time.Time, delta Savings) error
+	// SavingsSince returns the start of savings accounting, initialized with ts on first use
+	SavingsSince(ts time.Time) (time.Time, error)
+	// SavingsTotal returns the total savings of all periods
+	SavingsTotal() (Savings, error)
+}
@@ -0,0 +1,29 @@
+package store
+
+import "time"
+
+// Session is a single charging session
+type Session struct {
+	LoadPoint       string    `json:"loadpoint"`
+	Vehicle         string    `json:"vehicle"`
+	Identifier      string    `json:"identifier"`
+	Created         time.Time `json:"created"`
+	Finished        time.Time `json:"finished"`
+	MeterStart      float64   `json:"meterStart"`      // kWh, only available with energy meter
+	MeterStop       float64   `json:"meterStop"`       // kWh, only available with energy meter
+	ChargedEnergy   float64   `json:"chargedEnergy"`   // kWh
+	SolarPercentage float64   `json:"solarPercentage"` // %
+	Price           float64   `json:"price"`           // e.g. EUR
+}
+
+// SessionStore persists charging sessions
+type SessionStore interface {
+	// AddSession stores a finished charging session
+	AddSession(s Session) error
+}
+
+// Store persists savings and charging sessions
+type Store interface {
+	SavingsStore
+	SessionStore
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/loadpoint"
 	"github.com/evcc-io/evcc/core/site"
 	"github.com/evcc-io/evcc/hems/ocpp/profile"
 	"github.com/evcc-io/evcc/util"
@@ -14,17 +16,43 @@ import (
 	"github.com/denisbrodbeck/machineid"
 	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
 	ocppcore "github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/lorenzodonini/ocpp-go/ws"
 )
 
 // OCPP is an OCPP client
 type OCPP struct {
-	log  *util.Logger
-	site site.API
-	cp   ocpp16.ChargePoint
+	mu              sync.Mutex
+	log             *util.Logger
+	site            site.API
+	cp              ocpp16.ChargePoint
+	connectors      []*connector
+	stationProfiles []chargingProfile // charge point max profiles
 }
 
-const retryTimeout = 5 * time.Second
+// connector is the OCPP state of a loadpoint
+type connector struct {
+	lp       loadpoint.API
+	status   ocppcore.ChargePointStatus
+	txnID    int
+	idTag    string
+	profiles []chargingProfile // transaction and transaction default profiles
+	limit    float64           // effective charging profile limit, zero if not limited
+	stopped  bool              // transaction stopped remotely
+	limited  bool              // charging profile limit below min current
+	meter    float64           // energy register in Wh
+	energy   float64           // last charged energy in Wh
+}
+
+const (
+	retryTimeout = 5 * time.Second
+
+	// remoteSource identifies OCPP as source of remote demands
+	remoteSource = "ocpp"
+
+	// defaultIdTag is used for transactions not started remotely
+	defaultIdTag = "evcc"
+)
 
 // New generates OCPP chargepoint client
 func New(conf map[string]interface{}, site site.API) (*OCPP, error) {
@@ -58,10 +86,14 @@ func New(conf map[string]interface{}, site site.API) (*OCPP, error) {
 		cp:   cp,
 	}
 
+	for _, lp := range site.LoadPoints() {
+		s.connectors = append(s.connectors, &connector{lp: lp})
+	}
+
 	err := cp.Start(cc.URI)
 	if err == nil {
-		cp.SetCoreHandler(profile.NewCore(log, profile.GetDefaultConfig()))
-		cp.SetSmartChargingHandler(profile.NewSmartCharging(log))
+		cp.SetCoreHandler(profile.NewCore(log, profile.GetDefaultConfig(), s))
+		cp.SetSmartChargingHandler(profile.NewSmartCharging(log, s))
 
 		go s.errorHandler(ws.Errors())
 		go s.errorHandler(cp.Errors())
@@ -80,16 +112,15 @@ func (s *OCPP) errorHandler(errC <-chan error) {
 // Run executes the OCPP chargepoint client
 func (s *OCPP) Run() {
 	for {
-		for id, lp := range s.site.LoadPoints() {
-			connector := id + 1
+		// charging profile periods and expiry depend on time
+		s.mu.Lock()
+		s.updateLimits(time.Now())
+		s.mu.Unlock()
 
-			status := ocppcore.ChargePointStatusAvailable
-			if lp.GetStatus() == api.StatusC {
-				status = ocppcore.ChargePointStatusCharging
-			}
+		for id, c := range s.connectors {
+			connector := id + 1
 
-			s.log.DEBUG.Printf("send: lp-%d status: %+v", connector, status)
-			if _, err := s.cp.StatusNotification(connector, ocppcore.NoError, status); err != nil {
+			if err := s.update(connector, c); err != nil {
 				s.log.ERROR.Printf("lp-%d: %v", connector, err)
 			}
 		}
@@ -97,3 +128,129 @@ func (s *OCPP) Run() {
 		time.Sleep(retryTimeout)
 	}
 }
+
+// chargePointStatus maps the loadpoint status to the OCPP connector status
+func chargePointStatus(status api.ChargeStatus, transaction bool) ocppcore.ChargePointStatus {
+	switch status {
+	case api.StatusA:
+		return ocppcore.ChargePointStatusAvailable
+	case api.StatusB:
+		if transaction {
+			return ocppcore.ChargePointStatusSuspendedEV
+		}
+		return ocppcore.ChargePointStatusPreparing
+	case api.StatusC, api.StatusD:
+		return ocppcore.ChargePointStatusCharging
+	case api.StatusE, api.StatusF:
+		return ocppcore.ChargePointStatusFaulted
+	default:
+		return ocppcore.ChargePointStatusUnavailable
+	}
+}
+
+// update sends status, transaction and meter values of a single connector
+func (s *OCPP) update(connector int, c *connector) error {
+	status := c.lp.GetStatus()
+	connected := status == api.StatusB || status == api.StatusC || status == api.StatusD
+
+	s.mu.Lock()
+
+	// monotonous energy register from session energy
+	energy := c.lp.GetChargedEnergy()
+	if energy >= c.energy {
+		c.meter += energy - c.energy
+	} else {
+		c.meter += energy
+	}
+	c.energy = energy
+
+	// vehicle disconnected, remote stop only applies to the current session
+	if !connected && c.stopped {
+		c.stopped = false
+		s.remoteControl(c)
+	}
+
+	startTxn := connected && !c.stopped && c.txnID == 0
+	stopTxn := (!connected || c.stopped) && c.txnID != 0
+
+	idTag := c.idTag
+	if idTag == "" {
+		idTag = defaultIdTag
+	}
+
+	txnID, meter, stopped := c.txnID, int(c.meter), c.stopped
+	s.mu.Unlock()
+
+	if startTxn {
+		s.log.DEBUG.Printf("send: lp-%d start transaction: %s", connector, idTag)
+
+		res, err := s.cp.StartTransaction(connector, idTag, meter, types.NewDateTime(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		if res.IdTagInfo != nil && res.IdTagInfo.Status != types.AuthorizationStatusAccepted {
+			s.log.WARN.Printf("lp-%d: id tag %s: %s", connector, idTag, res.IdTagInfo.Status)
+		}
+
+		txnID = res.TransactionId
+
+		s.mu.Lock()
+		c.txnID = txnID
+		s.mu.Unlock()
+	}
+
+	if stopTxn {
+		reason := ocppcore.ReasonEVDisconnected
+		if stopped {
+			reason = ocppcore.ReasonRemote
+		}
+
+		s.log.DEBUG.Printf("send: lp-%d stop transaction: %d (%s)", connector, txnID, reason)
+
+		if _, err := s.cp.StopTransaction(meter, types.NewDateTime(time.Now()), txnID, func(request *ocppcore.StopTransactionRequest) {
+			request.Reason = reason
+		}); err != nil {
+			return err
+		}
+
+		txnID = 0
+
+		s.mu.Lock()
+		c.txnID = 0
+		c.idTag = ""
+
+		// transaction profiles end with the transaction
+		c.profiles = withoutProfiles(c.profiles, func(p chargingProfile) bool {
+			return p.ChargingProfilePurpose == types.ChargingProfilePurposeTxProfile
+		})
+		s.updateLimits(time.Now())
+		s.mu.Unlock()
+	}
+
+	if chargePointStatus := chargePointStatus(status, txnID != 0); chargePointStatus != c.status {
+		s.log.DEBUG.Printf("send: lp-%d status: %+v", connector, chargePointStatus)
+
+		if _, err := s.cp.StatusNotification(connector, ocppcore.NoError, chargePointStatus); err != nil {
+			return err
+		}
+
+		c.status = chargePointStatus
+	}
+
+	if txnID != 0 {
+		if _, err := s.cp.MeterValues(connector, []types.MeterValue{{
+			Timestamp: types.NewDateTime(time.Now()),
+			SampledValue: []types.SampledValue{
+				{Value: fmt.Sprintf("%d", meter), Measurand: types.MeasurandEnergyActiveImportRegister, Unit: types.UnitOfMeasureWh},
+				{Value: fmt.Sprintf("%.0f", c.lp.GetChargePower()), Measurand: types.MeasurandPowerActiveImport, Unit: types.UnitOfMeasureW},
+			},
+		}}, func(request *ocppcore.MeterValuesRequest) {
+			request.TransactionId = &txnID
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 )
 
@@ -99,14 +100,14 @@ func GetDefaultConfig() ConfigMap {
 	var cfg ConfigMap = make(map[string]core.ConfigurationKey)
 
 	// readonly
-	cfg.set(SupportedFeatureProfiles, true, core.ProfileName)
+	cfg.set(SupportedFeatureProfiles, true, core.ProfileName+","+smartcharging.ProfileName)
 	cfg.set(AuthorizeRemoteTxRequests, true, strconv.FormatBool(false))
 	cfg.set(GetConfigurationMaxKeys, true, strconv.FormatInt(50, intBase))
 	cfg.set(NumberOfConnectors, true, strconv.FormatInt(1, intBase))
 	cfg.set(LocalAuthListMaxLength, true, strconv.FormatInt(100, intBase))
 	cfg.set(SendLocalListMaxLength, true, strconv.FormatInt(20, intBase))
 	cfg.set(ChargeProfileMaxStackLevel, true, strconv.FormatInt(10, intBase))
-	cfg.set(ChargingScheduleAllowedChargingRateUnit, true, "Power")
+	cfg.set(ChargingScheduleAllowedChargingRateUnit, true, "Current,Power")
 	cfg.set(ChargingScheduleMaxPeriods, true, strconv.FormatInt(5, intBase))
 	cfg.set(MaxChargingProfilesInstalled, true, strconv.FormatInt(10, intBase))
 
@@ -119,13 +120,13 @@ func GetDefaultConfig() ConfigMap {
 	cfg.set(LocalAuthListEnabled, false, strconv.FormatBool(true))
 	cfg.set(LocalPreAuthorize, false, strconv.FormatBool(false))
 	cfg.set(MeterValuesAlignedData, false, string(types.MeasurandEnergyActiveExportRegister))
-	cfg.set(MeterValuesSampledData, false, string(types.MeasurandEnergyActiveExportRegister))
+	cfg.set(MeterValuesSampledData, false, string(types.MeasurandEnergyActiveImportRegister)+","+string(types.MeasurandPowerActiveImport))
 	cfg.set(MeterValueSampleInterval, false, strconv.FormatInt(5, intBase))
 	cfg.set(ResetRetries, false, strconv.FormatInt(10, intBase))
 	cfg.set(StopTransactionOnEVSideDisconnect, false, strconv.FormatBool(true))
 	cfg.set(StopTransactionOnInvalidID, false, strconv.FormatBool(true))
 	cfg.set(StopTxnAlignedData, false, strconv.FormatBool(true))
-	cfg.set(StopTxnSampledData, false, string(types.MeasurandEnergyActiveExportRegister))
+	cfg.set(StopTxnSampledData, false, string(types.MeasurandEnergyActiveImportRegister))
 	cfg.set(TransactionMessageAttempts, false, strconv.FormatInt(5, intBase))
 	cfg.set(TransactionMessageRetryInterval, false, strconv.FormatInt(60, intBase))
 	cfg.set(UnlockConnectorOnEVSideDisconnect, false, strconv.FormatBool(true))
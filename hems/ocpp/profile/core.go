@@ -9,12 +9,14 @@ import (
 type Core struct {
 	log           *util.Logger
 	configuration ConfigMap
+	handler       Handler
 }
 
-func NewCore(log *util.Logger, config ConfigMap) *Core {
+func NewCore(log *util.Logger, config ConfigMap, handler Handler) *Core {
 	return &Core{
 		log:           log,
 		configuration: config,
+		handler:       handler,
 	}
 }
 
@@ -33,11 +35,34 @@ func (s *Core) OnUnlockConnector(request *core.UnlockConnectorRequest) (confirma
 // OnRemoteStartTransaction handles the CS message
 func (s *Core) OnRemoteStartTransaction(request *core.RemoteStartTransactionRequest) (confirmation *core.RemoteStartTransactionConfirmation, err error) {
 	s.log.TRACE.Printf("recv: %s %+v", request.GetFeatureName(), request)
-	return core.NewRemoteStartTransactionConfirmation(types.RemoteStartStopStatusRejected), nil
+
+	var connector int
+	if request.ConnectorId != nil {
+		connector = *request.ConnectorId
+	}
+
+	if err := s.handler.RemoteStart(connector, request.IdTag); err != nil {
+		s.log.ERROR.Printf("%s: %v", request.GetFeatureName(), err)
+		return core.NewRemoteStartTransactionConfirmation(types.RemoteStartStopStatusRejected), nil
+	}
+
+	if request.ChargingProfile != nil {
+		if err := s.handler.SetChargingProfile(connector, request.ChargingProfile); err != nil {
+			s.log.ERROR.Printf("%s: %v", request.GetFeatureName(), err)
+		}
+	}
+
+	return core.NewRemoteStartTransactionConfirmation(types.RemoteStartStopStatusAccepted), nil
 }
 
 // OnRemoteStopTransaction handles the CS message
 func (s *Core) OnRemoteStopTransaction(request *core.RemoteStopTransactionRequest) (confirmation *core.RemoteStopTransactionConfirmation, err error) {
 	s.log.TRACE.Printf("recv: %s %+v", request.GetFeatureName(), request)
-	return core.NewRemoteStopTransactionConfirmation(types.RemoteStartStopStatusRejected), nil
+
+	if err := s.handler.RemoteStop(request.TransactionId); err != nil {
+		s.log.ERROR.Printf("%s: %v", request.GetFeatureName(), err)
+		return core.NewRemoteStopTransactionConfirmation(types.RemoteStartStopStatusRejected), nil
+	}
+
+	return core.NewRemoteStopTransactionConfirmation(types.RemoteStartStopStatusAccepted), nil
 }
@@ -0,0 +1,17 @@
+package profile
+
+import "github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+
+// Handler executes central system requests on the evcc loadpoints
+type Handler interface {
+	// RemoteStart starts a transaction. Connector 0 lets the charge point choose.
+	RemoteStart(connector int, idTag string) error
+	// RemoteStop stops a transaction
+	RemoteStop(transaction int) error
+	// SetChargingProfile applies the charging profile. Connector 0 applies to all connectors.
+	SetChargingProfile(connector int, profile *types.ChargingProfile) error
+	// ClearChargingProfile removes the charging profiles matching id, purpose and stack level if given. Connector 0 applies to all connectors.
+	ClearChargingProfile(connector int, id *int, purpose types.ChargingProfilePurposeType, stackLevel *int) error
+	// CompositeSchedule returns the effective charging schedule of the connector
+	CompositeSchedule(connector int) (*types.ChargingSchedule, error)
+}
@@ -1,34 +1,70 @@
 package profile
 
 import (
+	"time"
+
 	"github.com/evcc-io/evcc/util"
 	sc "github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 )
 
 type SmartCharging struct {
-	log *util.Logger
+	log     *util.Logger
+	handler Handler
 }
 
-func NewSmartCharging(log *util.Logger) *SmartCharging {
+func NewSmartCharging(log *util.Logger, handler Handler) *SmartCharging {
 	return &SmartCharging{
-		log: log,
+		log:     log,
+		handler: handler,
 	}
 }
 
 // OnSetChargingProfile handles the CS message
 func (s *SmartCharging) OnSetChargingProfile(request *sc.SetChargingProfileRequest) (confirmation *sc.SetChargingProfileConfirmation, err error) {
 	s.log.TRACE.Printf("recv: %s %+v", request.GetFeatureName(), request)
-	return sc.NewSetChargingProfileConfirmation(sc.ChargingProfileStatusRejected), nil
+
+	if err := s.handler.SetChargingProfile(request.ConnectorId, request.ChargingProfile); err != nil {
+		s.log.ERROR.Printf("%s: %v", request.GetFeatureName(), err)
+		return sc.NewSetChargingProfileConfirmation(sc.ChargingProfileStatusRejected), nil
+	}
+
+	return sc.NewSetChargingProfileConfirmation(sc.ChargingProfileStatusAccepted), nil
 }
 
 // OnClearChargingProfile handles the CS message
 func (s *SmartCharging) OnClearChargingProfile(request *sc.ClearChargingProfileRequest) (confirmation *sc.ClearChargingProfileConfirmation, err error) {
 	s.log.TRACE.Printf("recv: %s %+v", request.GetFeatureName(), request)
-	return sc.NewClearChargingProfileConfirmation(sc.ClearChargingProfileStatusUnknown), nil
+
+	var connector int
+	if request.ConnectorId != nil {
+		connector = *request.ConnectorId
+	}
+
+	if err := s.handler.ClearChargingProfile(connector, request.Id, request.ChargingProfilePurpose, request.StackLevel); err != nil {
+		s.log.ERROR.Printf("%s: %v", request.GetFeatureName(), err)
+		return sc.NewClearChargingProfileConfirmation(sc.ClearChargingProfileStatusUnknown), nil
+	}
+
+	return sc.NewClearChargingProfileConfirmation(sc.ClearChargingProfileStatusAccepted), nil
 }
 
 // OnGetCompositeSchedule handles the CS message
 func (s *SmartCharging) OnGetCompositeSchedule(request *sc.GetCompositeScheduleRequest) (confirmation *sc.GetCompositeScheduleConfirmation, err error) {
 	s.log.TRACE.Printf("recv: %s %+v", request.GetFeatureName(), request)
-	return sc.NewGetCompositeScheduleConfirmation(sc.GetCompositeScheduleStatusRejected), nil
+
+	schedule, err := s.handler.CompositeSchedule(request.ConnectorId)
+	if err != nil {
+		s.log.ERROR.Printf("%s: %v", request.GetFeatureName(), err)
+		return sc.NewGetCompositeScheduleConfirmation(sc.GetCompositeScheduleStatusRejected), nil
+	}
+
+	connector := request.ConnectorId
+
+	res := sc.NewGetCompositeScheduleConfirmation(sc.GetCompositeScheduleStatusAccepted)
+	res.ConnectorId = &connector
+	res.ScheduleStart = types.NewDateTime(time.Now())
+	res.ChargingSchedule = schedule
+
+	return res, nil
 }
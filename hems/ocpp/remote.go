@@ -0,0 +1,369 @@
+package ocpp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/evcc-io/evcc/core"
+	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/hems/ocpp/profile"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+var _ profile.Handler = (*OCPP)(nil)
+
+// connectorsByID returns the connectors addressed by the OCPP connector id, 0 addresses all connectors
+func (s *OCPP) connectorsByID(id int) ([]*connector, error) {
+	if id == 0 {
+		return s.connectors, nil
+	}
+
+	if id < 0 || id > len(s.connectors) {
+		return nil, fmt.Errorf("invalid connector: %d", id)
+	}
+
+	return s.connectors[id-1 : id], nil
+}
+
+// remoteControl applies the remote demand resulting from remote stop and charging profiles
+func (s *OCPP) remoteControl(c *connector) {
+	demand := loadpoint.RemoteEnable
+	if c.stopped || c.limited {
+		demand = loadpoint.RemoteHardDisable
+	}
+
+	c.lp.RemoteControl(remoteSource, demand)
+}
+
+// RemoteStart implements the profile.Handler interface
+func (s *OCPP) RemoteStart(id int, idTag string) error {
+	if id == 0 {
+		id = 1
+	}
+
+	connectors, err := s.connectorsByID(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := connectors[0]
+	if c.txnID != 0 && !c.stopped {
+		return errors.New("transaction already active")
+	}
+
+	// transaction is started once the vehicle is connected
+	c.idTag = idTag
+	c.stopped = false
+	s.remoteControl(c)
+
+	return nil
+}
+
+// RemoteStop implements the profile.Handler interface
+func (s *OCPP) RemoteStop(txnID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.connectors {
+		if c.txnID == txnID && txnID != 0 {
+			// transaction is stopped by next update
+			c.stopped = true
+			s.remoteControl(c)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown transaction: %d", txnID)
+}
+
+// errProfileExpired indicates that a charging profile will not become active anymore
+var errProfileExpired = errors.New("charging profile expired")
+
+// chargingProfile is a charging profile accepted by the charge point
+type chargingProfile struct {
+	*types.ChargingProfile
+	received time.Time // start of relative schedules
+}
+
+// activePeriod returns the charging schedule period active at the given time
+func activePeriod(profile *types.ChargingProfile, received, now time.Time) (types.ChargingSchedulePeriod, error) {
+	schedule := profile.ChargingSchedule
+	if schedule == nil || len(schedule.ChargingSchedulePeriod) == 0 {
+		return types.ChargingSchedulePeriod{}, errors.New("missing charging schedule")
+	}
+
+	if profile.ChargingProfileKind == types.ChargingProfileKindRecurring {
+		return types.ChargingSchedulePeriod{}, errors.New("recurring profiles not supported")
+	}
+
+	if profile.ValidTo != nil && now.After(profile.ValidTo.Time) {
+		return types.ChargingSchedulePeriod{}, errProfileExpired
+	}
+
+	// relative schedules start on reception
+	start := received
+	if profile.ChargingProfileKind == types.ChargingProfileKindAbsolute && schedule.StartSchedule != nil {
+		start = schedule.StartSchedule.Time
+	}
+
+	elapsed := int(now.Sub(start).Seconds())
+	if schedule.Duration != nil && elapsed > *schedule.Duration {
+		return types.ChargingSchedulePeriod{}, errProfileExpired
+	}
+
+	if profile.ValidFrom != nil && now.Before(profile.ValidFrom.Time) || elapsed < schedule.ChargingSchedulePeriod[0].StartPeriod {
+		return types.ChargingSchedulePeriod{}, errors.New("charging profile not yet active")
+	}
+
+	period := schedule.ChargingSchedulePeriod[0]
+	for _, p := range schedule.ChargingSchedulePeriod {
+		if p.StartPeriod <= elapsed && p.StartPeriod >= period.StartPeriod {
+			period = p
+		}
+	}
+
+	return period, nil
+}
+
+// profileLimit returns the current limit of the highest stack level profile active at the given time
+func profileLimit(profiles []chargingProfile, now time.Time, phases int) (float64, bool) {
+	var (
+		res   float64
+		level = -1
+	)
+
+	for _, p := range profiles {
+		if p.StackLevel <= level {
+			continue
+		}
+
+		period, err := activePeriod(p.ChargingProfile, p.received, now)
+		if err != nil {
+			continue
+		}
+
+		current := period.Limit
+		if p.ChargingSchedule.ChargingRateUnit == types.ChargingRateUnitWatts {
+			periodPhases := phases
+			if period.NumberPhases != nil {
+				periodPhases = *period.NumberPhases
+			}
+			if periodPhases == 0 {
+				periodPhases = 3
+			}
+
+			current = period.Limit / (core.Voltage * float64(periodPhases))
+		}
+
+		res, level = current, p.StackLevel
+	}
+
+	return res, level >= 0
+}
+
+// withoutProfiles returns the profiles not matching the filter
+func withoutProfiles(profiles []chargingProfile, match func(chargingProfile) bool) []chargingProfile {
+	var res []chargingProfile
+	for _, p := range profiles {
+		if !match(p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// expired matches profiles that will not become active anymore
+func expired(now time.Time) func(chargingProfile) bool {
+	return func(p chargingProfile) bool {
+		_, err := activePeriod(p.ChargingProfile, p.received, now)
+		return errors.Is(err, errProfileExpired)
+	}
+}
+
+// replacedBy matches profiles replaced by the given profile
+func replacedBy(profile *types.ChargingProfile) func(chargingProfile) bool {
+	return func(p chargingProfile) bool {
+		return p.ChargingProfileId == profile.ChargingProfileId ||
+			p.StackLevel == profile.StackLevel && p.ChargingProfilePurpose == profile.ChargingProfilePurpose
+	}
+}
+
+// updateLimits removes expired profiles and applies the active profile limits to all connectors.
+// Transaction profiles take precedence over default profiles, the station limit is shared by all connectors.
+func (s *OCPP) updateLimits(now time.Time) {
+	s.stationProfiles = withoutProfiles(s.stationProfiles, expired(now))
+	stationLimit, stationLimited := profileLimit(s.stationProfiles, now, 0)
+
+	for _, c := range s.connectors {
+		c.profiles = withoutProfiles(c.profiles, expired(now))
+
+		var txProfiles, defaultProfiles []chargingProfile
+		for _, p := range c.profiles {
+			if p.ChargingProfilePurpose == types.ChargingProfilePurposeTxProfile {
+				txProfiles = append(txProfiles, p)
+			} else {
+				defaultProfiles = append(defaultProfiles, p)
+			}
+		}
+
+		phases := c.lp.GetPhases()
+
+		// transaction profiles only apply during a transaction
+		var limit float64
+		var limited bool
+		if c.txnID != 0 {
+			limit, limited = profileLimit(txProfiles, now, phases)
+		}
+		if !limited {
+			limit, limited = profileLimit(defaultProfiles, now, phases)
+		}
+
+		if stationLimited {
+			current := stationLimit / float64(len(s.connectors))
+			if !limited || current < limit {
+				limit, limited = current, true
+			}
+		}
+
+		below := limited && limit < c.lp.GetMinCurrent()
+
+		c.limit = 0
+		if limited && !below {
+			c.limit = limit
+		}
+		c.lp.RemoteCurrentLimit(remoteSource, c.limit)
+
+		if below != c.limited {
+			c.limited = below
+			s.remoteControl(c)
+		}
+	}
+}
+
+// SetChargingProfile implements the profile.Handler interface.
+// Profiles are kept per connector and re-evaluated periodically.
+func (s *OCPP) SetChargingProfile(id int, profile *types.ChargingProfile) error {
+	connectors, err := s.connectorsByID(id)
+	if err != nil {
+		return err
+	}
+
+	switch profile.ChargingProfilePurpose {
+	case types.ChargingProfilePurposeChargePointMaxProfile:
+		if id != 0 {
+			return errors.New("station profile requires connector 0")
+		}
+	case types.ChargingProfilePurposeTxProfile:
+		if id == 0 {
+			return errors.New("transaction profile requires connector")
+		}
+	}
+
+	if schedule := profile.ChargingSchedule; schedule == nil || len(schedule.ChargingSchedulePeriod) == 0 {
+		return errors.New("missing charging schedule")
+	}
+
+	if profile.ChargingProfileKind == types.ChargingProfileKindRecurring {
+		return errors.New("recurring profiles not supported")
+	}
+
+	now := time.Now()
+	if _, err := activePeriod(profile, now, now); errors.Is(err, errProfileExpired) {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := chargingProfile{ChargingProfile: profile, received: now}
+
+	switch profile.ChargingProfilePurpose {
+	case types.ChargingProfilePurposeChargePointMaxProfile:
+		s.stationProfiles = append(withoutProfiles(s.stationProfiles, replacedBy(profile)), p)
+
+	case types.ChargingProfilePurposeTxProfile:
+		c := connectors[0]
+		if c.txnID == 0 {
+			return errors.New("no active transaction")
+		}
+		c.profiles = append(withoutProfiles(c.profiles, replacedBy(profile)), p)
+
+	default:
+		for _, c := range connectors {
+			c.profiles = append(withoutProfiles(c.profiles, replacedBy(profile)), p)
+		}
+	}
+
+	s.updateLimits(now)
+
+	return nil
+}
+
+// ClearChargingProfile implements the profile.Handler interface
+func (s *OCPP) ClearChargingProfile(id int, profileID *int, purpose types.ChargingProfilePurposeType, stackLevel *int) error {
+	connectors, err := s.connectorsByID(id)
+	if err != nil {
+		return err
+	}
+
+	match := func(p chargingProfile) bool {
+		return (profileID == nil || p.ChargingProfileId == *profileID) &&
+			(purpose == "" || p.ChargingProfilePurpose == purpose) &&
+			(stackLevel == nil || p.StackLevel == *stackLevel)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cleared int
+
+	if id == 0 {
+		remaining := withoutProfiles(s.stationProfiles, match)
+		cleared += len(s.stationProfiles) - len(remaining)
+		s.stationProfiles = remaining
+	}
+
+	for _, c := range connectors {
+		remaining := withoutProfiles(c.profiles, match)
+		cleared += len(c.profiles) - len(remaining)
+		c.profiles = remaining
+	}
+
+	if cleared == 0 {
+		return errors.New("no matching charging profile")
+	}
+
+	s.updateLimits(time.Now())
+
+	return nil
+}
+
+// CompositeSchedule implements the profile.Handler interface
+func (s *OCPP) CompositeSchedule(id int) (*types.ChargingSchedule, error) {
+	connectors, err := s.connectorsByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var limit float64
+	for _, c := range connectors {
+		if !c.stopped && !c.limited {
+			current := c.lp.GetMaxCurrent()
+			if c.limit > 0 {
+				current = math.Min(current, c.limit)
+			}
+			limit += current
+		}
+	}
+
+	return types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, limit)), nil
+}
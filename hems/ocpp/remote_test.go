@@ -0,0 +1,172 @@
+package ocpp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/core"
+	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+)
+
+func TestActivePeriod(t *testing.T) {
+	now := time.Now()
+
+	profile := &types.ChargingProfile{
+		ChargingProfileKind: types.ChargingProfileKindAbsolute,
+		ChargingSchedule: types.NewChargingSchedule(types.ChargingRateUnitAmperes,
+			types.NewChargingSchedulePeriod(0, 16),
+			types.NewChargingSchedulePeriod(3600, 6),
+			types.NewChargingSchedulePeriod(7200, 0),
+		),
+	}
+
+	for _, tc := range []struct {
+		start    time.Duration
+		expected float64
+	}{
+		{0, 16},
+		{30 * time.Minute, 16},
+		{90 * time.Minute, 6},
+		{3 * time.Hour, 0},
+	} {
+		profile.ChargingSchedule.StartSchedule = types.NewDateTime(now.Add(-tc.start))
+
+		period, err := activePeriod(profile, now, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if period.Limit != tc.expected {
+			t.Errorf("%v: expected %.0fA, got %.0fA", tc.start, tc.expected, period.Limit)
+		}
+	}
+
+	// expired
+	duration := 3600
+	profile.ChargingSchedule.Duration = &duration
+
+	if _, err := activePeriod(profile, now, now); err != errProfileExpired {
+		t.Errorf("expected expired schedule, got %v", err)
+	}
+
+	// relative schedules start on reception
+	profile.ChargingProfileKind = types.ChargingProfileKindRelative
+
+	if period, err := activePeriod(profile, now.Add(-30*time.Minute), now); err != nil || period.Limit != 16 {
+		t.Errorf("unexpected relative period: %v %v", period, err)
+	}
+
+	if _, err := activePeriod(profile, now.Add(-90*time.Minute), now); err != errProfileExpired {
+		t.Errorf("expected expired relative schedule, got %v", err)
+	}
+}
+
+// limitLoadPoint records the remote limits applied to a loadpoint
+type limitLoadPoint struct {
+	loadpoint.API
+	limit  float64
+	demand loadpoint.RemoteDemand
+}
+
+func (lp *limitLoadPoint) GetPhases() int                                   { return 3 }
+func (lp *limitLoadPoint) GetMinCurrent() float64                           { return 6 }
+func (lp *limitLoadPoint) RemoteCurrentLimit(_ string, current float64)     { lp.limit = current }
+func (lp *limitLoadPoint) RemoteControl(_ string, d loadpoint.RemoteDemand) { lp.demand = d }
+
+func TestUpdateLimits(t *testing.T) {
+	now := time.Now()
+	duration := 3600
+
+	profile := func(id, level int, purpose types.ChargingProfilePurposeType, limit float64) chargingProfile {
+		return chargingProfile{
+			ChargingProfile: &types.ChargingProfile{
+				ChargingProfileId:      id,
+				StackLevel:             level,
+				ChargingProfilePurpose: purpose,
+				ChargingProfileKind:    types.ChargingProfileKindRelative,
+				ChargingSchedule: &types.ChargingSchedule{
+					Duration:               &duration,
+					ChargingRateUnit:       types.ChargingRateUnitAmperes,
+					ChargingSchedulePeriod: []types.ChargingSchedulePeriod{types.NewChargingSchedulePeriod(0, limit)},
+				},
+			},
+			received: now,
+		}
+	}
+
+	lp := &limitLoadPoint{}
+	c := &connector{lp: lp}
+	s := &OCPP{connectors: []*connector{c}}
+
+	// highest stack level wins
+	c.profiles = []chargingProfile{
+		profile(1, 1, types.ChargingProfilePurposeTxDefaultProfile, 10),
+		profile(2, 0, types.ChargingProfilePurposeTxDefaultProfile, 8),
+	}
+
+	s.updateLimits(now)
+	if lp.limit != 10 {
+		t.Errorf("stack level: expected 10A, got %.3gA", lp.limit)
+	}
+
+	// transaction profile overrides default profile during transaction
+	c.profiles = append(c.profiles, profile(3, 0, types.ChargingProfilePurposeTxProfile, 12))
+
+	s.updateLimits(now)
+	if lp.limit != 10 {
+		t.Errorf("no transaction: expected 10A, got %.3gA", lp.limit)
+	}
+
+	c.txnID = 1
+
+	s.updateLimits(now)
+	if lp.limit != 12 {
+		t.Errorf("transaction: expected 12A, got %.3gA", lp.limit)
+	}
+
+	// station limit applies if lower
+	s.stationProfiles = []chargingProfile{profile(4, 0, types.ChargingProfilePurposeChargePointMaxProfile, 4)}
+
+	s.updateLimits(now)
+	if lp.limit != 0 || lp.demand != loadpoint.RemoteHardDisable {
+		t.Errorf("station: expected disabled, got %.3gA %v", lp.limit, lp.demand)
+	}
+
+	// expired profiles are removed and release the limit
+	s.updateLimits(now.Add(2 * time.Hour))
+	if lp.limit != 0 || lp.demand != loadpoint.RemoteEnable {
+		t.Errorf("expired: expected released, got %.3gA %v", lp.limit, lp.demand)
+	}
+
+	if len(c.profiles) != 0 || len(s.stationProfiles) != 0 {
+		t.Errorf("expired: expected profiles removed, got %d %d", len(c.profiles), len(s.stationProfiles))
+	}
+}
+
+func TestProfileLimitPhases(t *testing.T) {
+	core.Voltage = 230 // V
+
+	now := time.Now()
+	onePhase := 1
+
+	profile := func(level int, phases *int) chargingProfile {
+		period := types.NewChargingSchedulePeriod(0, 6900)
+		period.NumberPhases = phases
+
+		return chargingProfile{
+			ChargingProfile: &types.ChargingProfile{
+				StackLevel:          level,
+				ChargingProfileKind: types.ChargingProfileKindRelative,
+				ChargingSchedule:    types.NewChargingSchedule(types.ChargingRateUnitWatts, period),
+			},
+			received: now,
+		}
+	}
+
+	// phases of a lower stack level period don't apply to the next profile
+	limit, ok := profileLimit([]chargingProfile{profile(0, &onePhase), profile(1, nil)}, now, 3)
+	if !ok || limit != 10 {
+		t.Errorf("expected 10A, got %.3gA (%t)", limit, ok)
+	}
+}
@@ -0,0 +1,65 @@
+package semp
+
+import (
+	"sync"
+	"time"
+)
+
+// energySample is a charged energy reading in Wh
+type energySample struct {
+	ts     time.Time
+	energy float64
+}
+
+// powerAverage calculates the average power from charged energy over the averaging interval
+type powerAverage struct {
+	mu       sync.Mutex
+	interval time.Duration
+	samples  []energySample
+}
+
+func newPowerAverage(interval time.Duration) *powerAverage {
+	return &powerAverage{
+		interval: interval,
+	}
+}
+
+// add adds an energy reading and drops samples outside the averaging interval
+func (a *powerAverage) add(ts time.Time, energy float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// charged energy is reset when the vehicle connects
+	if n := len(a.samples); n > 0 && energy < a.samples[n-1].energy {
+		a.samples = nil
+	}
+
+	a.samples = append(a.samples, energySample{ts: ts, energy: energy})
+
+	// keep one sample at or before the interval start
+	var i int
+	for i < len(a.samples)-1 && !a.samples[i+1].ts.After(ts.Add(-a.interval)) {
+		i++
+	}
+
+	a.samples = a.samples[i:]
+}
+
+// power returns the average power in W over the available samples
+func (a *powerAverage) power() (float64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.samples) < 2 {
+		return 0, false
+	}
+
+	first, last := a.samples[0], a.samples[len(a.samples)-1]
+
+	duration := last.ts.Sub(first.ts)
+	if duration <= 0 {
+		return 0, false
+	}
+
+	return (last.energy - first.energy) / duration.Hours(), true
+}
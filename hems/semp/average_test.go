@@ -0,0 +1,36 @@
+package semp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPowerAverage(t *testing.T) {
+	a := newPowerAverage(time.Minute)
+	ts := time.Now()
+
+	if _, ok := a.power(); ok {
+		t.Error("unexpected average without samples")
+	}
+
+	// 3.6kW for a minute, then 7.2kW
+	a.add(ts, 0)
+	a.add(ts.Add(time.Minute), 60)
+
+	if p, ok := a.power(); !ok || p != 3600 {
+		t.Errorf("expected 3600W, got %.0fW", p)
+	}
+
+	a.add(ts.Add(2*time.Minute), 180)
+
+	if p, ok := a.power(); !ok || p != 7200 {
+		t.Errorf("expected 7200W, got %.0fW", p)
+	}
+
+	// energy reset on vehicle connect
+	a.add(ts.Add(3*time.Minute), 0)
+
+	if _, ok := a.power(); ok {
+		t.Error("unexpected average after energy reset")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"os"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/denisbrodbeck/machineid"
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core"
 	"github.com/evcc-io/evcc/core/loadpoint"
 	"github.com/evcc-io/evcc/core/site"
 	"github.com/evcc-io/evcc/server"
@@ -32,6 +34,9 @@ const (
 	sempCharger      = "EVCharger"
 	basePath         = "/semp"
 	maxAge           = 1800
+
+	averagingInterval = 60 * time.Second // power averaging interval
+	sampleInterval    = 5 * time.Second  // charged energy sampling interval
 )
 
 var (
@@ -50,6 +55,7 @@ type SEMP struct {
 	hostURI      string
 	port         int
 	site         site.API
+	averages     []*powerAverage
 }
 
 // New generates SEMP Gateway listening at /semp endpoint
@@ -100,6 +106,10 @@ func New(conf map[string]interface{}, site site.API, httpd *server.HTTPd) (*SEMP
 		controllable: cc.AllowControl,
 	}
 
+	for range site.LoadPoints() {
+		s.averages = append(s.averages, newPowerAverage(averagingInterval))
+	}
+
 	// find external port
 	_, port, err := net.SplitHostPort(httpd.Addr)
 	if err == nil {
@@ -137,10 +147,16 @@ func (s *SEMP) Run() {
 	}
 
 	ticker := time.NewTicker(maxAge * time.Second / 2)
+	sampler := time.NewTicker(sampleInterval)
 
 ANNOUNCE:
 	for {
 		select {
+		case ts := <-sampler.C:
+			for id, lp := range s.site.LoadPoints() {
+				s.averages[id].add(ts, lp.GetChargedEnergy())
+				s.releaseRecommendation(lp)
+			}
 		case <-ticker.C:
 			for _, ad := range ads {
 				if err := ad.Alive(); err != nil {
@@ -386,7 +402,7 @@ func (s *SEMP) deviceInfo(id int, lp loadpoint.API) DeviceInfo {
 		},
 		Characteristics: Characteristics{
 			MinPowerConsumption: int(lp.GetMinPower()),
-			MaxPowerConsumption: int(lp.GetMaxPower()),
+			MaxPowerConsumption: int(s.maxPower(lp)),
 		},
 	}
 
@@ -401,8 +417,17 @@ func (s *SEMP) allDeviceInfo() (res []DeviceInfo) {
 	return res
 }
 
+// maxPower returns the max power based on the configured max current, ignoring recommendations
+func (s *SEMP) maxPower(lp loadpoint.API) float64 {
+	return core.Voltage * lp.GetMaxCurrent() * float64(lp.GetPhases())
+}
+
 func (s *SEMP) deviceStatus(id int, lp loadpoint.API) DeviceStatus {
-	chargePower := lp.GetChargePower()
+	// energy based average, current power until enough samples are available
+	chargePower, ok := s.averages[id].power()
+	if !ok {
+		chargePower = lp.GetChargePower()
+	}
 
 	status := lp.GetStatus()
 	mode := lp.GetMode()
@@ -420,7 +445,7 @@ func (s *SEMP) deviceStatus(id int, lp loadpoint.API) DeviceStatus {
 		EMSignalsAccepted: s.controllable && isPV && connected,
 		PowerInfo: PowerInfo{
 			AveragePower:      int(chargePower),
-			AveragingInterval: 60,
+			AveragingInterval: int(averagingInterval.Seconds()),
 		},
 		Status: deviceStatus,
 	}
@@ -448,6 +473,13 @@ func (s *SEMP) planningRequest(id int, lp loadpoint.API) (res PlanningRequest) {
 		latestEnd = 24 * 3600
 	}
 
+	// target charging must finish until target time
+	targetTime := lp.GetTargetTime()
+	targetActive := mode != api.ModeNow && !targetTime.IsZero() && targetTime.After(time.Now())
+	if targetActive {
+		latestEnd = int(time.Until(targetTime) / time.Second)
+	}
+
 	// remaining max energy demand in Wh
 	chargeRemainingEnergy := lp.GetRemainingEnergy()
 	maxEnergy := int(chargeRemainingEnergy)
@@ -458,11 +490,11 @@ func (s *SEMP) planningRequest(id int, lp loadpoint.API) (res PlanningRequest) {
 	}
 
 	minEnergy := maxEnergy
-	if mode == api.ModePV {
+	if mode == api.ModePV && !targetActive {
 		minEnergy = 0
 	}
 
-	maxPowerConsumption := int(lp.GetMaxPower())
+	maxPowerConsumption := int(s.maxPower(lp))
 	minPowerConsumption := int(lp.GetMinPower())
 	if mode == api.ModeNow {
 		minPowerConsumption = maxPowerConsumption
@@ -495,6 +527,32 @@ func (s *SEMP) allPlanningRequest() (res []PlanningRequest) {
 	return res
 }
 
+// applyRecommendation limits the loadpoint charge current to the recommended power.
+// The limit is transient and leaves the configured max current unchanged.
+func (s *SEMP) applyRecommendation(lp loadpoint.API, dev DeviceControl) {
+	if !dev.On || dev.RecommendedPowerConsumption <= 0 {
+		lp.RemoteCurrentLimit(sempController, 0)
+		return
+	}
+
+	phases := lp.GetPhases()
+	if phases == 0 {
+		phases = 3
+	}
+
+	current := dev.RecommendedPowerConsumption / (core.Voltage * float64(phases))
+	s.log.DEBUG.Printf("recommended power: %.0fW (%.1fA)", dev.RecommendedPowerConsumption, current)
+
+	lp.RemoteCurrentLimit(sempController, math.Max(lp.GetMinCurrent(), current))
+}
+
+// releaseRecommendation removes the recommended current limit once the loadpoint leaves pv mode
+func (s *SEMP) releaseRecommendation(lp loadpoint.API) {
+	if mode := lp.GetMode(); mode != api.ModeMinPV && mode != api.ModePV {
+		lp.RemoteCurrentLimit(sempController, 0)
+	}
+}
+
 func (s *SEMP) deviceControlHandler(w http.ResponseWriter, r *http.Request) {
 	var msg EM2Device
 
@@ -531,6 +589,7 @@ func (s *SEMP) deviceControlHandler(w http.ResponseWriter, r *http.Request) {
 			}
 
 			lp.RemoteControl(sempController, demand)
+			s.applyRecommendation(lp, dev)
 		}
 	}
 
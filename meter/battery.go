@@ -0,0 +1,68 @@
+package meter
+
+import (
+	"fmt"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/provider"
+	"github.com/evcc-io/evcc/util"
+)
+
+// batteryModeSetter is a single int setter invoked with a fixed value
+type batteryModeSetter struct {
+	set   func(int64) error
+	value int64
+}
+
+// batteryModeSetterFromConfig creates a battery mode setter from per-mode lists of provider setters.
+// Each setter is written with its configured value.
+func batteryModeSetterFromConfig(other map[string][]provider.Config) (func(api.BatteryMode) error, error) {
+	modes := make(map[api.BatteryMode][]batteryModeSetter)
+
+	for key, configs := range other {
+		mode, err := api.BatteryModeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("batterymode: %w", err)
+		}
+
+		for idx, pc := range configs {
+			var cc struct {
+				Value int64
+				Other map[string]interface{} `mapstructure:",remain"`
+			}
+
+			if err := util.DecodeOther(pc.Other, &cc); err != nil {
+				return nil, fmt.Errorf("batterymode %s[%d]: %w", mode, idx, err)
+			}
+
+			pc.Other = cc.Other
+
+			set, err := provider.NewIntSetterFromConfig("batterymode", pc)
+			if err != nil {
+				return nil, fmt.Errorf("batterymode %s[%d]: %w", mode, idx, err)
+			}
+
+			modes[mode] = append(modes[mode], batteryModeSetter{set: set, value: cc.Value})
+		}
+	}
+
+	// batteries must always be returned to normal operation
+	if _, ok := modes[api.BatteryNormal]; !ok {
+		return nil, fmt.Errorf("batterymode: missing %s", api.BatteryNormal)
+	}
+
+	return func(mode api.BatteryMode) error {
+		setters, ok := modes[mode]
+		if !ok {
+			return fmt.Errorf("batterymode not supported: %s", mode)
+		}
+
+		for _, s := range setters {
+			if err := s.set(s.value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
@@ -13,15 +13,16 @@ func init() {
 	registry.Add(api.Custom, NewConfigurableFromConfig)
 }
 
-//go:generate go run ../cmd/tools/decorate.go -f decorateMeter -b api.Meter -t "api.MeterEnergy,TotalEnergy,func() (float64, error)" -t "api.MeterCurrent,Currents,func() (float64, float64, float64, error)" -t "api.Battery,SoC,func() (float64, error)"
+//go:generate go run ../cmd/tools/decorate.go -f decorateMeter -b api.Meter -t "api.MeterEnergy,TotalEnergy,func() (float64, error)" -t "api.MeterCurrent,Currents,func() (float64, float64, float64, error)" -t "api.Battery,SoC,func() (float64, error)" -t "api.BatteryController,SetBatteryMode,func(api.BatteryMode) error"
 
 // NewConfigurableFromConfig creates api.Meter from config
 func NewConfigurableFromConfig(other map[string]interface{}) (api.Meter, error) {
 	cc := struct {
-		Power    provider.Config
-		Energy   *provider.Config  // optional
-		SoC      *provider.Config  // optional
-		Currents []provider.Config // optional
+		Power       provider.Config
+		Energy      *provider.Config             // optional
+		SoC         *provider.Config             // optional
+		Currents    []provider.Config            // optional
+		BatteryMode map[string][]provider.Config // optional
 	}{}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
@@ -73,7 +74,20 @@ func NewConfigurableFromConfig(other map[string]interface{}) (api.Meter, error)
 		}
 	}
 
-	res := m.Decorate(totalEnergyG, currentsG, batterySoCG)
+	// decorate Meter with BatteryController
+	var batteryModeS func(api.BatteryMode) error
+	if len(cc.BatteryMode) > 0 {
+		if batterySoCG == nil {
+			return nil, errors.New("batterymode requires soc")
+		}
+
+		batteryModeS, err = batteryModeSetterFromConfig(cc.BatteryMode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := m.Decorate(totalEnergyG, currentsG, batterySoCG, batteryModeS)
 
 	return res, nil
 }
@@ -113,8 +127,9 @@ func (m *Meter) Decorate(
 	totalEnergy func() (float64, error),
 	currents func() (float64, float64, float64, error),
 	batterySoC func() (float64, error),
+	batteryMode func(api.BatteryMode) error,
 ) api.Meter {
-	return decorateMeter(m, totalEnergy, currents, batterySoC)
+	return decorateMeter(m, totalEnergy, currents, batterySoC, batteryMode)
 }
 
 // CurrentPower implements the api.Meter interface
@@ -55,7 +55,7 @@ func NewMovingAverageFromConfig(other map[string]interface{}) (api.Meter, error)
 		currents = m.Currents
 	}
 
-	res := meter.Decorate(totalEnergy, currents, batterySoC)
+	res := meter.Decorate(totalEnergy, currents, batterySoC, nil)
 
 	return res, nil
 }
@@ -7,6 +7,15 @@ const (
 	MeterURI   = "/api/meters/aggregates"
 	BatteryURI = "/api/system_status/soe"
 	LoginURI   = "/api/login/Basic"
+
+	OperationURI       = "/api/operation"
+	ConfigCompletedURI = "/api/config/completed"
+)
+
+// Operation modes
+const (
+	ModeSelfConsumption = "self_consumption"
+	ModeBackup          = "backup"
 )
 
 // MeterResponse is the /api/system_status/aggregates response
@@ -29,3 +38,9 @@ type MeterResponse map[string]struct {
 type BatteryResponse struct {
 	Percentage float64 `json:"percentage"`
 }
+
+// Operation is the /api/operation request and response
+type Operation struct {
+	RealMode             string  `json:"real_mode"`
+	BackupReservePercent float64 `json:"backup_reserve_percent"`
+}
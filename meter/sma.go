@@ -8,6 +8,7 @@ import (
 	"text/tabwriter"
 
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/provider"
 	"github.com/evcc-io/evcc/provider/sma"
 	"github.com/evcc-io/evcc/util"
 	"gitlab.com/bboehmke/sunny"
@@ -24,14 +25,15 @@ func init() {
 	registry.Add("sma", NewSMAFromConfig)
 }
 
-//go:generate go run ../cmd/tools/decorate.go -f decorateSMA -b *SMA -r api.Meter -t "api.Battery,SoC,func() (float64, error)"
+//go:generate go run ../cmd/tools/decorate.go -f decorateSMA -b *SMA -r api.Meter -t "api.Battery,SoC,func() (float64, error)" -t "api.BatteryController,SetBatteryMode,func(api.BatteryMode) error"
 
 // NewSMAFromConfig creates a SMA Meter from generic config
 func NewSMAFromConfig(other map[string]interface{}) (api.Meter, error) {
 	cc := struct {
 		URI, Password, Interface string
 		Serial                   uint32
-		Scale                    float64 // power only
+		Scale                    float64                      // power only
+		BatteryMode              map[string][]provider.Config // optional
 	}{
 		Password: "0000",
 		Scale:    1,
@@ -41,11 +43,19 @@ func NewSMAFromConfig(other map[string]interface{}) (api.Meter, error) {
 		return nil, err
 	}
 
-	return NewSMA(cc.URI, cc.Password, cc.Interface, cc.Serial, cc.Scale)
+	var batteryMode func(api.BatteryMode) error
+	if len(cc.BatteryMode) > 0 {
+		var err error
+		if batteryMode, err = batteryModeSetterFromConfig(cc.BatteryMode); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewSMA(cc.URI, cc.Password, cc.Interface, cc.Serial, cc.Scale, batteryMode)
 }
 
 // NewSMA creates a SMA Meter
-func NewSMA(uri, password, iface string, serial uint32, scale float64) (api.Meter, error) {
+func NewSMA(uri, password, iface string, serial uint32, scale float64, batteryMode func(api.BatteryMode) error) (api.Meter, error) {
 	sm := &SMA{
 		uri:   uri,
 		scale: scale,
@@ -94,7 +104,12 @@ func NewSMA(uri, password, iface string, serial uint32, scale float64) (api.Mete
 		}
 	}
 
-	return decorateSMA(sm, soc), nil
+	// battery control requires battery inverter
+	if batteryMode != nil && soc == nil {
+		return nil, errors.New("batterymode requires battery inverter")
+	}
+
+	return decorateSMA(sm, soc, batteryMode), nil
 }
 
 // CurrentPower implements the api.Meter interface
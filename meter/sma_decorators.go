@@ -6,12 +6,12 @@ import (
 	"github.com/evcc-io/evcc/api"
 )
 
-func decorateSMA(base *SMA, battery func() (float64, error)) api.Meter {
+func decorateSMA(base *SMA, battery func() (float64, error), batteryController func(api.BatteryMode) error) api.Meter {
 	switch {
-	case battery == nil:
+	case battery == nil && batteryController == nil:
 		return base
 
-	case battery != nil:
+	case battery != nil && batteryController == nil:
 		return &struct {
 			*SMA
 			api.Battery
@@ -21,6 +21,32 @@ func decorateSMA(base *SMA, battery func() (float64, error)) api.Meter {
 				battery: battery,
 			},
 		}
+
+	case battery == nil && batteryController != nil:
+		return &struct {
+			*SMA
+			api.BatteryController
+		}{
+			SMA: base,
+			BatteryController: &decorateSMABatteryControllerImpl{
+				batteryController: batteryController,
+			},
+		}
+
+	case battery != nil && batteryController != nil:
+		return &struct {
+			*SMA
+			api.Battery
+			api.BatteryController
+		}{
+			SMA: base,
+			Battery: &decorateSMABatteryImpl{
+				battery: battery,
+			},
+			BatteryController: &decorateSMABatteryControllerImpl{
+				batteryController: batteryController,
+			},
+		}
 	}
 
 	return nil
@@ -33,3 +59,11 @@ type decorateSMABatteryImpl struct {
 func (impl *decorateSMABatteryImpl) SoC() (float64, error) {
 	return impl.battery()
 }
+
+type decorateSMABatteryControllerImpl struct {
+	batteryController func(api.BatteryMode) error
+}
+
+func (impl *decorateSMABatteryControllerImpl) SetBatteryMode(p0 api.BatteryMode) error {
+	return impl.batteryController(p0)
+}
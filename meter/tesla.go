@@ -21,18 +21,20 @@ import (
 type Tesla struct {
 	*request.Helper
 	uri, usage, password string
+	operation            *powerwall.Operation // operation settings to restore in normal mode
 }
 
 func init() {
 	registry.Add("tesla", NewTeslaFromConfig)
 }
 
-//go:generate go run ../cmd/tools/decorate.go -f decorateTesla -b *Tesla -r api.Meter -t "api.MeterEnergy,TotalEnergy,func() (float64, error)" -t "api.Battery,SoC,func() (float64, error)"
+//go:generate go run ../cmd/tools/decorate.go -f decorateTesla -b *Tesla -r api.Meter -t "api.MeterEnergy,TotalEnergy,func() (float64, error)" -t "api.Battery,SoC,func() (float64, error)" -t "api.BatteryController,SetBatteryMode,func(api.BatteryMode) error"
 
 // NewTeslaFromConfig creates a Tesla Powerwall Meter from generic config
 func NewTeslaFromConfig(other map[string]interface{}) (api.Meter, error) {
 	cc := struct {
 		URI, Usage, Password string
+		BatteryControl       bool // optional
 	}{}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
@@ -60,11 +62,15 @@ func NewTeslaFromConfig(other map[string]interface{}) (api.Meter, error) {
 		cc.Usage = "solar"
 	}
 
-	return NewTesla(cc.URI, cc.Usage, cc.Password)
+	if cc.BatteryControl && strings.ToLower(cc.Usage) != "battery" {
+		return nil, errors.New("batterycontrol requires battery usage")
+	}
+
+	return NewTesla(cc.URI, cc.Usage, cc.Password, cc.BatteryControl)
 }
 
 // NewTesla creates a Tesla Meter
-func NewTesla(uri, usage, password string) (api.Meter, error) {
+func NewTesla(uri, usage, password string, batteryControl bool) (api.Meter, error) {
 	log := util.NewLogger("tesla").Redact(password)
 
 	m := &Tesla{
@@ -89,13 +95,19 @@ func NewTesla(uri, usage, password string) (api.Meter, error) {
 		totalEnergy = m.totalEnergy
 	}
 
-	// decorate api.BatterySoC
+	// decorate api.BatterySoC and api.BatteryController
 	var batterySoC func() (float64, error)
+	var batteryMode func(api.BatteryMode) error
 	if usage == "battery" {
 		batterySoC = m.batterySoC
+
+		// only write operation settings if explicitly requested
+		if batteryControl {
+			batteryMode = m.setBatteryMode
+		}
 	}
 
-	return decorateTesla(m, totalEnergy, batterySoC), nil
+	return decorateTesla(m, totalEnergy, batterySoC, batteryMode), nil
 }
 
 // Login calls login and saves the returned cookie
@@ -158,3 +170,48 @@ func (m *Tesla) batterySoC() (float64, error) {
 
 	return res.Percentage, err
 }
+
+// setBatteryMode implements the api.BatteryController interface
+func (m *Tesla) setBatteryMode(mode api.BatteryMode) error {
+	// remember user settings for restoring normal operation
+	if m.operation == nil {
+		var res powerwall.Operation
+		if err := m.GetJSON(m.uri+powerwall.OperationURI, &res); err != nil {
+			return err
+		}
+		m.operation = &res
+	}
+
+	op := *m.operation
+
+	switch mode {
+	case api.BatteryNormal:
+	case api.BatteryHold:
+		soc, err := m.batterySoC()
+		if err != nil {
+			return err
+		}
+		op.RealMode = powerwall.ModeSelfConsumption
+		op.BackupReservePercent = soc
+	case api.BatteryCharge:
+		op.RealMode = powerwall.ModeBackup
+		op.BackupReservePercent = 100
+	default:
+		return api.ErrNotAvailable
+	}
+
+	req, err := request.New(http.MethodPost, m.uri+powerwall.OperationURI, request.MarshalJSON(op), request.JSONEncoding)
+	if err == nil {
+		if _, err = m.DoBody(req); err == nil {
+			// apply settings
+			_, err = m.GetBody(m.uri + powerwall.ConfigCompletedURI)
+		}
+	}
+
+	// pick up changed user settings next time
+	if err == nil && mode == api.BatteryNormal {
+		m.operation = nil
+	}
+
+	return err
+}
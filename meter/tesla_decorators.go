@@ -6,12 +6,12 @@ import (
 	"github.com/evcc-io/evcc/api"
 )
 
-func decorateTesla(base *Tesla, meterEnergy func() (float64, error), battery func() (float64, error)) api.Meter {
+func decorateTesla(base *Tesla, meterEnergy func() (float64, error), battery func() (float64, error), batteryController func(api.BatteryMode) error) api.Meter {
 	switch {
-	case battery == nil && meterEnergy == nil:
+	case battery == nil && batteryController == nil && meterEnergy == nil:
 		return base
 
-	case battery == nil && meterEnergy != nil:
+	case battery == nil && batteryController == nil && meterEnergy != nil:
 		return &struct {
 			*Tesla
 			api.MeterEnergy
@@ -22,7 +22,7 @@ func decorateTesla(base *Tesla, meterEnergy func() (float64, error), battery fun
 			},
 		}
 
-	case battery != nil && meterEnergy == nil:
+	case battery != nil && batteryController == nil && meterEnergy == nil:
 		return &struct {
 			*Tesla
 			api.Battery
@@ -33,7 +33,7 @@ func decorateTesla(base *Tesla, meterEnergy func() (float64, error), battery fun
 			},
 		}
 
-	case battery != nil && meterEnergy != nil:
+	case battery != nil && batteryController == nil && meterEnergy != nil:
 		return &struct {
 			*Tesla
 			api.Battery
@@ -47,6 +47,66 @@ func decorateTesla(base *Tesla, meterEnergy func() (float64, error), battery fun
 				meterEnergy: meterEnergy,
 			},
 		}
+
+	case battery == nil && batteryController != nil && meterEnergy == nil:
+		return &struct {
+			*Tesla
+			api.BatteryController
+		}{
+			Tesla: base,
+			BatteryController: &decorateTeslaBatteryControllerImpl{
+				batteryController: batteryController,
+			},
+		}
+
+	case battery == nil && batteryController != nil && meterEnergy != nil:
+		return &struct {
+			*Tesla
+			api.BatteryController
+			api.MeterEnergy
+		}{
+			Tesla: base,
+			BatteryController: &decorateTeslaBatteryControllerImpl{
+				batteryController: batteryController,
+			},
+			MeterEnergy: &decorateTeslaMeterEnergyImpl{
+				meterEnergy: meterEnergy,
+			},
+		}
+
+	case battery != nil && batteryController != nil && meterEnergy == nil:
+		return &struct {
+			*Tesla
+			api.Battery
+			api.BatteryController
+		}{
+			Tesla: base,
+			Battery: &decorateTeslaBatteryImpl{
+				battery: battery,
+			},
+			BatteryController: &decorateTeslaBatteryControllerImpl{
+				batteryController: batteryController,
+			},
+		}
+
+	case battery != nil && batteryController != nil && meterEnergy != nil:
+		return &struct {
+			*Tesla
+			api.Battery
+			api.BatteryController
+			api.MeterEnergy
+		}{
+			Tesla: base,
+			Battery: &decorateTeslaBatteryImpl{
+				battery: battery,
+			},
+			BatteryController: &decorateTeslaBatteryControllerImpl{
+				batteryController: batteryController,
+			},
+			MeterEnergy: &decorateTeslaMeterEnergyImpl{
+				meterEnergy: meterEnergy,
+			},
+		}
 	}
 
 	return nil
@@ -60,6 +120,14 @@ func (impl *decorateTeslaBatteryImpl) SoC() (float64, error) {
 	return impl.battery()
 }
 
+type decorateTeslaBatteryControllerImpl struct {
+	batteryController func(api.BatteryMode) error
+}
+
+func (impl *decorateTeslaBatteryControllerImpl) SetBatteryMode(p0 api.BatteryMode) error {
+	return impl.batteryController(p0)
+}
+
 type decorateTeslaMeterEnergyImpl struct {
 	meterEnergy func() (float64, error)
 }
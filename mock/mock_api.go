@@ -346,6 +346,20 @@ func (mr *MockVehicleMockRecorder) OnIdentified() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnIdentified", reflect.TypeOf((*MockVehicle)(nil).OnIdentified))
 }
 
+// Plans mocks base method.
+func (m *MockVehicle) Plans() []api.Plan {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Plans")
+	ret0, _ := ret[0].([]api.Plan)
+	return ret0
+}
+
+// Plans indicates an expected call of Plans.
+func (mr *MockVehicleMockRecorder) Plans() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plans", reflect.TypeOf((*MockVehicle)(nil).Plans))
+}
+
 // SoC mocks base method.
 func (m *MockVehicle) SoC() (float64, error) {
 	m.ctrl.T.Helper()
@@ -22,6 +22,7 @@ type Modbus struct {
 	conn   *modbus.Connection
 	device meters.Device
 	op     modbus.Operation
+	encode func(float64) []byte // multiple register write encoding
 	scale  float64
 }
 
@@ -131,10 +132,17 @@ func NewModbusFromConfig(other map[string]interface{}) (IntProvider, error) {
 	}
 
 	// register configured
+	var encode func(float64) []byte
 	if cc.Register.Decode != "" {
 		if op.MBMD, err = modbus.RegisterOperation(cc.Register); err != nil {
 			return nil, err
 		}
+
+		if op.MBMD.FuncCode == gridx.FuncCodeWriteMultipleRegisters {
+			if encode, err = modbus.RegisterEncoding(cc.Register); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	mb := &Modbus{
@@ -142,6 +150,7 @@ func NewModbusFromConfig(other map[string]interface{}) (IntProvider, error) {
 		conn:   conn,
 		device: device,
 		op:     op,
+		encode: encode,
 		scale:  cc.Scale,
 	}
 	return mb, nil
@@ -276,6 +285,9 @@ func (m *Modbus) IntSetter(param string) func(int64) error {
 			switch op.FuncCode {
 			case gridx.FuncCodeWriteSingleRegister:
 				_, err = m.conn.WriteSingleRegister(op.OpCode, uval)
+			case gridx.FuncCodeWriteMultipleRegisters:
+				// write register length and encoding according to decode type
+				_, err = m.conn.WriteMultipleRegisters(op.OpCode, op.ReadLen, m.encode(m.scale*float64(val)))
 			default:
 				err = fmt.Errorf("unknown function code %d", op.FuncCode)
 			}
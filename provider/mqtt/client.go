@@ -35,12 +35,13 @@ type Config struct {
 
 // Client encapsulates mqtt publish/subscribe functions
 type Client struct {
-	log      *util.Logger
-	mux      sync.Mutex
-	Client   paho.Client
-	broker   string
-	Qos      byte
-	listener map[string][]func(string)
+	log       *util.Logger
+	mux       sync.Mutex
+	Client    paho.Client
+	broker    string
+	Qos       byte
+	listener  map[string][]func(string)
+	onConnect []func()
 }
 
 type Option func(*paho.ClientOptions)
@@ -94,10 +95,15 @@ func (m *Client) ConnectionLostHandler(client paho.Client, reason error) {
 	m.log.ERROR.Printf("%s connection lost: %v", m.broker, reason.Error())
 }
 
-// ConnectionHandler restores listeners
+// ConnectionHandler restores listeners, publishes online status if a last will is configured and invokes connect handlers
 func (m *Client) ConnectionHandler(client paho.Client) {
 	m.log.DEBUG.Printf("%s connected", m.broker)
 
+	if or := client.OptionsReader(); or.WillEnabled() {
+		token := client.Publish(or.WillTopic(), or.WillQos(), true, "online")
+		go m.WaitForToken(token)
+	}
+
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -105,6 +111,17 @@ func (m *Client) ConnectionHandler(client paho.Client) {
 		m.log.DEBUG.Printf("%s subscribe %s", m.broker, topic)
 		go m.listen(topic)
 	}
+
+	for _, cb := range m.onConnect {
+		go cb()
+	}
+}
+
+// OnConnect registers a callback invoked after each (re)connect
+func (m *Client) OnConnect(cb func()) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.onConnect = append(m.onConnect, cb)
 }
 
 // Publish synchronously publishes payload using client qos
@@ -12,6 +12,11 @@ type Sender interface {
 	Send(title, msg string)
 }
 
+// EventSender implements sending messages including the event and its attributes
+type EventSender interface {
+	SendEvent(ev Event, title, msg string, attr map[string]interface{})
+}
+
 // EventTemplate is the push message template for an event
 type EventTemplate struct {
 	Title, Msg string
@@ -37,6 +42,11 @@ func NewMessengerFromConfig(typ string, other map[string]interface{}) (res Sende
 		if err = util.DecodeOther(other, &cc); err == nil {
 			res, err = NewShoutrrrMessenger(cc.URI)
 		}
+	case "webhook":
+		var cc webhookConfig
+		if err = util.DecodeOther(other, &cc); err == nil {
+			res, err = NewWebhookMessenger(cc.URI, cc.Headers, cc.Secret)
+		}
 	default:
 		err = fmt.Errorf("unknown messenger type: %s", typ)
 	}
@@ -33,8 +33,8 @@ func (h *Hub) Add(sender Sender) {
 	h.sender = append(h.sender, sender)
 }
 
-// apply applies the event template to the content to produce the actual message
-func (h *Hub) apply(ev Event, template string) (string, error) {
+// attributes returns the cached site and event loadpoint values
+func (h *Hub) attributes(ev Event) map[string]interface{} {
 	attr := make(map[string]interface{})
 
 	// let cache catch up, refs reverted https://github.com/evcc-io/evcc/pull/445
@@ -42,12 +42,12 @@ func (h *Hub) apply(ev Event, template string) (string, error) {
 
 	// get all values from cache
 	for _, p := range h.cache.All() {
-		if p.LoadPoint == nil || ev.LoadPoint == p.LoadPoint {
+		if p.LoadPoint == nil || ev.LoadPoint != nil && *ev.LoadPoint == *p.LoadPoint {
 			attr[p.Key] = p.Val
 		}
 	}
 
-	return util.ReplaceFormatted(template, attr)
+	return attr
 }
 
 // Run is the Hub's main publishing loop
@@ -62,20 +62,26 @@ func (h *Hub) Run(events <-chan Event) {
 			continue
 		}
 
-		title, err := h.apply(ev, definition.Title)
+		attr := h.attributes(ev)
+
+		title, err := util.ReplaceFormatted(definition.Title, attr)
 		if err != nil {
 			log.ERROR.Printf("invalid title template for %s: %v", ev.Event, err)
 			continue
 		}
 
-		msg, err := h.apply(ev, definition.Msg)
+		msg, err := util.ReplaceFormatted(definition.Msg, attr)
 		if err != nil {
 			log.ERROR.Printf("invalid message template for %s: %v", ev.Event, err)
 			continue
 		}
 
 		for _, sender := range h.sender {
-			go sender.Send(title, msg)
+			if s, ok := sender.(EventSender); ok {
+				go s.SendEvent(ev, title, msg, attr)
+			} else {
+				go sender.Send(title, msg)
+			}
 		}
 	}
 }
@@ -0,0 +1,126 @@
+package push
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/evcc-io/evcc/util"
+	"github.com/evcc-io/evcc/util/request"
+)
+
+// webhookSignatureHeader carries the hex encoded HMAC-SHA256 of the request body
+const webhookSignatureHeader = "X-Evcc-Signature"
+
+// Webhook implements the webhook messenger
+type Webhook struct {
+	*request.Helper
+	uri     string
+	headers map[string]string
+	secret  []byte
+}
+
+type webhookConfig struct {
+	URI     string
+	Headers map[string]string
+	Secret  string
+}
+
+// webhookPayload is the json document posted to the webhook
+type webhookPayload struct {
+	Event      string                 `json:"event,omitempty"`
+	LoadPoint  *int                   `json:"loadpoint,omitempty"` // 1-based like mqtt topics
+	Title      string                 `json:"title"`
+	Message    string                 `json:"message"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// NewWebhookMessenger creates new webhook messenger
+func NewWebhookMessenger(uri string, headers map[string]string, secret string) (*Webhook, error) {
+	if uri == "" {
+		return nil, errors.New("webhook: missing uri")
+	}
+
+	m := &Webhook{
+		Helper:  request.NewHelper(util.NewLogger("webhook")),
+		uri:     uri,
+		headers: headers,
+	}
+
+	if secret != "" {
+		m.secret = []byte(secret)
+	}
+
+	return m, nil
+}
+
+// Send sends the message without event details
+func (m *Webhook) Send(title, msg string) {
+	m.send(webhookPayload{Title: title, Message: msg})
+}
+
+// SendEvent sends the message including event and attributes
+func (m *Webhook) SendEvent(ev Event, title, msg string, attr map[string]interface{}) {
+	var lp *int
+	if ev.LoadPoint != nil {
+		id := *ev.LoadPoint + 1
+		lp = &id
+	}
+
+	m.send(webhookPayload{
+		Event:      ev.Event,
+		LoadPoint:  lp,
+		Title:      title,
+		Message:    msg,
+		Attributes: webhookAttributes(attr),
+	})
+}
+
+// webhookAttributes returns the attributes that can be encoded as json, e.g. excluding NaN values
+func webhookAttributes(attr map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(attr))
+
+	for k, v := range attr {
+		if _, err := json.Marshal(v); err != nil {
+			log.DEBUG.Printf("webhook: skipping attribute %s: %v", k, err)
+			continue
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
+
+// sign returns the hex encoded HMAC-SHA256 signature of the body
+func (m *Webhook) sign(body []byte) string {
+	mac := hmac.New(sha256.New, m.secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Webhook) send(payload webhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.ERROR.Printf("webhook: %v", err)
+		return
+	}
+
+	req, err := request.New(http.MethodPost, m.uri, bytes.NewReader(body), request.JSONEncoding, m.headers)
+	if err != nil {
+		log.ERROR.Printf("webhook: %v", err)
+		return
+	}
+
+	if m.secret != nil {
+		req.Header.Set(webhookSignatureHeader, m.sign(body))
+	}
+
+	if _, err := m.DoBody(req); err != nil {
+		log.ERROR.Printf("webhook: %v", err)
+	}
+}
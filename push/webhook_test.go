@@ -0,0 +1,64 @@
+package push
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var (
+		res       webhookPayload
+		signature string
+		header    string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(webhookSignatureHeader)
+		header = r.Header.Get("X-Custom")
+
+		if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	m, err := NewWebhookMessenger(srv.URL, map[string]string{"X-Custom": "foo"}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// loadpoint index is published 1-based
+	lp := 0
+	m.SendEvent(Event{Event: "start", LoadPoint: &lp}, "title", "msg", map[string]interface{}{
+		"chargePower": 1000.0,
+		"vehicleSoC":  math.NaN(),
+	})
+
+	if res.Event != "start" || res.LoadPoint == nil || *res.LoadPoint != 1 || res.Title != "title" || res.Message != "msg" {
+		t.Errorf("unexpected payload: %+v", res)
+	}
+
+	if res.Attributes["chargePower"] != 1000.0 || len(res.Attributes) != 1 {
+		t.Errorf("unexpected attributes: %v", res.Attributes)
+	}
+
+	if header != "foo" {
+		t.Errorf("missing custom header")
+	}
+
+	id := 1
+	body, _ := json.Marshal(webhookPayload{
+		Event:      "start",
+		LoadPoint:  &id,
+		Title:      "title",
+		Message:    "msg",
+		Attributes: map[string]interface{}{"chargePower": 1000.0},
+	})
+
+	if signature != m.sign(body) {
+		t.Errorf("invalid signature: %s", signature)
+	}
+}
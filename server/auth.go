@@ -0,0 +1,314 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	authCookieName  = "evcc_session"
+	sessionLifetime = 30 * 24 * time.Hour
+)
+
+// Scope is the permission level of an authenticated request
+type Scope int
+
+// Scopes
+const (
+	ScopeNone Scope = iota
+	ScopeRead
+	ScopeControl
+)
+
+// ScopeString converts string to Scope
+func ScopeString(scope string) (Scope, error) {
+	switch strings.ToLower(scope) {
+	case "read":
+		return ScopeRead, nil
+	case "control", "":
+		return ScopeControl, nil
+	default:
+		return ScopeNone, fmt.Errorf("invalid scope: %s", scope)
+	}
+}
+
+// AuthConfig is the authentication configuration
+type AuthConfig struct {
+	Password string
+	Tokens   []TokenConfig
+}
+
+// TokenConfig is an API token with its scope
+type TokenConfig struct {
+	Token string
+	Scope string // read or control (default)
+}
+
+// Auth authenticates and authorizes http requests by login session or API token
+type Auth struct {
+	mu       sync.Mutex
+	password string
+	tokens   map[string]Scope
+	sessions map[string]time.Time
+}
+
+// NewAuth creates the request authenticator. Authentication is disabled if neither password nor tokens are configured.
+func NewAuth(conf AuthConfig) (*Auth, error) {
+	a := &Auth{
+		password: conf.Password,
+		tokens:   make(map[string]Scope),
+		sessions: make(map[string]time.Time),
+	}
+
+	for _, t := range conf.Tokens {
+		if t.Token == "" {
+			return nil, errors.New("missing token")
+		}
+
+		scope, err := ScopeString(t.Scope)
+		if err != nil {
+			return nil, err
+		}
+
+		a.tokens[t.Token] = scope
+	}
+
+	return a, nil
+}
+
+// Enabled returns true if authentication is required
+func (a *Auth) Enabled() bool {
+	return a != nil && (a.password != "" || len(a.tokens) > 0)
+}
+
+// newSession creates a login session and returns its id
+func (a *Auth) newSession() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	id := hex.EncodeToString(b)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// remove expired sessions
+	for k, exp := range a.sessions {
+		if time.Now().After(exp) {
+			delete(a.sessions, k)
+		}
+	}
+
+	a.sessions[id] = time.Now().Add(sessionLifetime)
+
+	return id, nil
+}
+
+// validSession checks if the session id is known and not expired
+func (a *Auth) validSession(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	exp, ok := a.sessions[id]
+	return ok && time.Now().Before(exp)
+}
+
+// removeSession removes a login session
+func (a *Auth) removeSession(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, id)
+}
+
+// Scope returns the request's scope from API token or login session
+func (a *Auth) Scope(r *http.Request) Scope {
+	if !a.Enabled() {
+		return ScopeControl
+	}
+
+	// api token from header or query for websocket clients
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	if token != "" {
+		for t, scope := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return scope
+			}
+		}
+	}
+
+	if cookie, err := r.Cookie(authCookieName); err == nil && a.validSession(cookie.Value) {
+		return ScopeControl
+	}
+
+	return ScopeNone
+}
+
+// requiredScope returns the scope required for the request method
+func requiredScope(r *http.Request) Scope {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return ScopeRead
+	default:
+		return ScopeControl
+	}
+}
+
+// Handler is a middleware that rejects requests without sufficient scope
+func (a *Auth) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// cors preflight requests carry no credentials
+		if r.Method == http.MethodOptions {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		switch scope := a.Scope(r); {
+		case scope == ScopeNone:
+			jsonError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		case scope < requiredScope(r):
+			jsonError(w, http.StatusForbidden, errors.New("forbidden"))
+		default:
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// loginHandler creates a login session from json or form encoded password
+func (a *Auth) loginHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var password string
+		form := !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+
+		if form {
+			password = r.FormValue("password")
+		} else {
+			var req struct {
+				Password string `json:"password"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				jsonError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			password = req.Password
+		}
+
+		if a.password == "" || subtle.ConstantTimeCompare([]byte(a.password), []byte(password)) != 1 {
+			if form {
+				http.Redirect(w, r, "/login?failed", http.StatusSeeOther)
+				return
+			}
+
+			jsonError(w, http.StatusUnauthorized, errors.New("invalid password"))
+			return
+		}
+
+		id, err := a.newSession()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     authCookieName,
+			Value:    id,
+			Path:     "/",
+			Expires:  time.Now().Add(sessionLifetime),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+
+		if form {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		jsonResult(w, true)
+	}
+}
+
+// logoutHandler removes the login session
+func (a *Auth) logoutHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie(authCookieName); err == nil {
+			a.removeSession(cookie.Value)
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:   authCookieName,
+			Path:   "/",
+			MaxAge: -1,
+		})
+
+		jsonResult(w, false)
+	}
+}
+
+// statusHandler returns if authentication is enabled and the request's scope
+func (a *Auth) statusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scopes := map[Scope]string{ScopeNone: "none", ScopeRead: "read", ScopeControl: "control"}
+
+		res := struct {
+			Enabled bool   `json:"enabled"`
+			Scope   string `json:"scope"`
+		}{
+			Enabled: a.Enabled(),
+			Scope:   scopes[a.Scope(r)],
+		}
+
+		jsonResult(w, res)
+	}
+}
+
+const loginPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>evcc</title>
+</head>
+<body style="font-family: sans-serif; display: flex; justify-content: center; margin-top: 10vh">
+<form method="post" action="/api/auth/login">
+<h1>evcc</h1>
+<p><input type="password" name="password" placeholder="Password" autofocus></p>
+<p><button type="submit">Login</button></p>
+</form>
+</body>
+</html>
+`
+
+// loginPageHandler serves the login form for the web UI
+func loginPageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		_, _ = w.Write([]byte(loginPage))
+	}
+}
+
+// uiHandler redirects unauthenticated web UI requests to the login page
+func (a *Auth) uiHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.password != "" && a.Scope(r) == ScopeNone {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
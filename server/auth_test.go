@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthHandler(t *testing.T) {
+	auth, err := NewAuth(AuthConfig{
+		Password: "secret",
+		Tokens: []TokenConfig{
+			{Token: "reader", Scope: "read"},
+			{Token: "writer"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tc := []struct {
+		method, token string
+		status        int
+	}{
+		{http.MethodGet, "", http.StatusUnauthorized},
+		{http.MethodGet, "invalid", http.StatusUnauthorized},
+		{http.MethodGet, "reader", http.StatusOK},
+		{http.MethodPost, "reader", http.StatusForbidden},
+		{http.MethodPost, "writer", http.StatusOK},
+		{http.MethodOptions, "", http.StatusOK},
+	}
+
+	for _, tc := range tc {
+		req := httptest.NewRequest(tc.method, "/api/state", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.token, tc.status, w.Code)
+		}
+	}
+}
+
+func TestAuthLogin(t *testing.T) {
+	auth, _ := NewAuth(AuthConfig{Password: "secret"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	auth.loginHandler()(w, req)
+
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || len(cookies) != 1 {
+		t.Fatalf("login failed: %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/loadpoints/0/mode/pv", nil)
+	req.AddCookie(cookies[0])
+
+	if scope := auth.Scope(req); scope != ScopeControl {
+		t.Errorf("expected control scope, got %v", scope)
+	}
+}
+
+func TestAuthDisabled(t *testing.T) {
+	auth, _ := NewAuth(AuthConfig{})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/loadpoints/0/mode/pv", nil)
+	if scope := auth.Scope(req); scope != ScopeControl {
+		t.Errorf("expected control scope, got %v", scope)
+	}
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/evcc-io/evcc/util"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Instance is the database singleton
+var Instance *DB
+
+// DB is a key/value store persisting JSON-encoded values in buckets
+type DB struct {
+	log *util.Logger
+	db  *bolt.DB
+}
+
+// New opens or creates the database at the given path
+func New(path string) (*DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		log: util.NewLogger("db"),
+		db:  db,
+	}, nil
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Put stores the JSON-encoded value under key in bucket
+func (d *DB) Put(bucket, key string, val interface{}) error {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(key), b)
+	})
+}
+
+// Get decodes the value stored under key in bucket. Returns false if key does not exist.
+func (d *DB) Get(bucket, key string, val interface{}) (bool, error) {
+	var b []byte
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		if bkt := tx.Bucket([]byte(bucket)); bkt != nil {
+			if v := bkt.Get([]byte(key)); v != nil {
+				b = append(b, v...)
+			}
+		}
+		return nil
+	})
+
+	if err != nil || b == nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(b, val)
+}
+
+// ForEach iterates all key/value pairs of bucket in key order
+func (d *DB) ForEach(bucket string, fn func(key string, val []byte) error) error {
+	return d.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(bucket))
+		if bkt == nil {
+			return nil
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/evcc-io/evcc/core/store"
+)
+
+const (
+	savingsBucket = "savings"
+	metaBucket    = "meta"
+	savingsSince  = "savingsSince"
+	dayFormat     = "2006-01-02"
+)
+
+// Savings periods
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+	PeriodYear  = "year"
+)
+
+var _ store.SavingsStore = (*DB)(nil)
+
+// AddSavings adds the values to the given day's savings record
+func (d *DB) AddSavings(day time.Time, delta store.Savings) error {
+	key := day.Format(dayFormat)
+
+	var res store.Savings
+	if _, err := d.Get(savingsBucket, key, &res); err != nil {
+		return err
+	}
+
+	res.Period = key
+	res.Add(delta)
+
+	return d.Put(savingsBucket, key, res)
+}
+
+// SavingsSince returns the start of savings accounting. The start is initialized with ts on first use.
+func (d *DB) SavingsSince(ts time.Time) (time.Time, error) {
+	var res time.Time
+
+	ok, err := d.Get(metaBucket, savingsSince, &res)
+	if err == nil && !ok {
+		res = ts
+		err = d.Put(metaBucket, savingsSince, res)
+	}
+
+	return res, err
+}
+
+// Savings returns the savings aggregated per day, month or year
+func (d *DB) Savings(period string) ([]store.Savings, error) {
+	var length int
+	switch period {
+	case PeriodDay:
+		length = len("2006-01-02")
+	case PeriodMonth:
+		length = len("2006-01")
+	case PeriodYear:
+		length = len("2006")
+	default:
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+
+	var res []store.Savings
+
+	err := d.ForEach(savingsBucket, func(key string, val []byte) error {
+		var s store.Savings
+		if err := json.Unmarshal(val, &s); err != nil {
+			return err
+		}
+
+		// keys are ordered, aggregate into last entry if period matches
+		s.Period = key[:length]
+		if len(res) > 0 && res[len(res)-1].Period == s.Period {
+			res[len(res)-1].Add(s)
+		} else {
+			res = append(res, s)
+		}
+
+		return nil
+	})
+
+	return res, err
+}
+
+// SavingsTotal returns the total savings of all periods
+func (d *DB) SavingsTotal() (store.Savings, error) {
+	var res store.Savings
+
+	years, err := d.Savings(PeriodYear)
+	for _, s := range years {
+		res.Add(s)
+	}
+
+	return res, err
+}
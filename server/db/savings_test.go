@@ -0,0 +1,66 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/core/store"
+)
+
+func TestSavings(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "evcc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, day := range []time.Time{
+		time.Date(2021, 12, 30, 0, 0, 0, 0, time.Local),
+		time.Date(2021, 12, 31, 0, 0, 0, 0, time.Local),
+		time.Date(2021, 12, 31, 12, 0, 0, 0, time.Local),
+		time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local),
+	} {
+		if err := db.AddSavings(day, store.Savings{GridCharged: 1, SelfConsumptionCharged: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tc := []struct {
+		period  string
+		entries int
+		first   float64
+	}{
+		{PeriodDay, 3, 1},
+		{PeriodMonth, 2, 3},
+		{PeriodYear, 2, 3},
+	}
+
+	for _, tc := range tc {
+		res, err := db.Savings(tc.period)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res) != tc.entries {
+			t.Errorf("%s: expected %d entries, got %v", tc.period, tc.entries, res)
+		}
+
+		if len(res) > 0 && res[0].GridCharged != tc.first {
+			t.Errorf("%s: expected %.0fkWh, got %v", tc.period, tc.first, res[0])
+		}
+	}
+
+	total, err := db.SavingsTotal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total.GridCharged != 4 || total.SelfConsumptionCharged != 8 {
+		t.Errorf("unexpected total: %v", total)
+	}
+
+	if _, err := db.Savings("week"); err == nil {
+		t.Error("expected error")
+	}
+}
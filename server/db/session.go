@@ -0,0 +1,38 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/evcc-io/evcc/core/store"
+)
+
+const (
+	sessionsBucket = "sessions"
+	sessionKey     = "2006-01-02T15:04:05.000000000Z07:00"
+)
+
+var _ store.SessionStore = (*DB)(nil)
+
+// AddSession stores a finished charging session
+func (d *DB) AddSession(s store.Session) error {
+	key := fmt.Sprintf("%s/%s", s.Created.UTC().Format(sessionKey), s.LoadPoint)
+	return d.Put(sessionsBucket, key, s)
+}
+
+// Sessions returns all charging sessions ordered by creation time
+func (d *DB) Sessions() ([]store.Session, error) {
+	res := []store.Session{}
+
+	err := d.ForEach(sessionsBucket, func(key string, val []byte) error {
+		var s store.Session
+		if err := json.Unmarshal(val, &s); err != nil {
+			return err
+		}
+
+		res = append(res, s)
+		return nil
+	})
+
+	return res, err
+}
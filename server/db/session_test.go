@@ -0,0 +1,44 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/core/store"
+)
+
+func TestSessions(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "evcc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+
+	for _, s := range []store.Session{
+		{LoadPoint: "garage", Created: now.Add(time.Hour), ChargedEnergy: 2},
+		{LoadPoint: "carport", Created: now, ChargedEnergy: 1},
+		{LoadPoint: "garage", Created: now, ChargedEnergy: 3},
+	} {
+		if err := db.AddSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := db.Sessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 3 {
+		t.Fatalf("expected 3 sessions, got %v", res)
+	}
+
+	for i, energy := range []float64{1, 3, 2} {
+		if res[i].ChargedEnergy != energy {
+			t.Errorf("session %d: expected %.0fkWh, got %v", i, energy, res[i])
+		}
+	}
+}
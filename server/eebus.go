@@ -40,6 +40,8 @@ type EEBus struct {
 	clients           map[string]EEBusClientCBs
 	connectedClients  map[string]ship.Conn
 	discoveredClients map[string]*zeroconf.ServiceEntry
+
+	LPC *EEBusLPC // LPC controllable system, nil if not configured
 }
 
 var EEBusInstance *EEBus
@@ -52,6 +54,7 @@ func NewEEBus(other map[string]interface{}) (*EEBus, error) {
 		Certificate struct {
 			Public, Private []byte
 		}
+		LPC map[string]interface{}
 	}{
 		Uri: ":4712",
 	}
@@ -103,6 +106,12 @@ func NewEEBus(other map[string]interface{}) (*EEBus, error) {
 		discoveredClients: make(map[string]*zeroconf.ServiceEntry),
 	}
 
+	if cc.LPC != nil {
+		if c.LPC, err = NewEEBusLPC(c, cc.LPC); err != nil {
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -211,11 +220,8 @@ func (c *EEBus) discoverDNS(results <-chan *zeroconf.ServiceEntry, connector fun
 	for entry := range results {
 		c.log.TRACE.Println("mDNS:", entry.HostName, entry.AddrIPv4, entry.Text)
 
-		for _, typ := range entry.Text {
-			if strings.HasPrefix(typ, "type=") && typ == "type=EVSE" {
-				connector(entry)
-			}
-		}
+		// only registered SKIs are connected, e.g. wallboxes and grid operator control boxes
+		connector(entry)
 	}
 }
 
@@ -0,0 +1,557 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dylanmei/iso8601"
+	"github.com/evcc-io/eebus/communication"
+	"github.com/evcc-io/eebus/device/entity"
+	"github.com/evcc-io/eebus/device/feature"
+	"github.com/evcc-io/eebus/ship"
+	"github.com/evcc-io/eebus/spine"
+	"github.com/evcc-io/eebus/spine/model"
+	"github.com/evcc-io/evcc/util"
+)
+
+const (
+	lpcDefaultFailsafeLimit    = 4200 // W, §14a EnWG minimum power
+	lpcDefaultFailsafeDuration = 2 * time.Hour
+	lpcDefaultHeartbeatTimeout = 120 * time.Second
+
+	// failsafe duration range as required by the use case
+	lpcMinFailsafeDuration = 2 * time.Hour
+	lpcMaxFailsafeDuration = 24 * time.Hour
+
+	lpcLimitID               = 0
+	lpcFailsafeLimitKeyID    = 0
+	lpcFailsafeDurationKeyID = 1
+
+	// identifiers not contained in the spine model
+	lpcScopeActivePowerLimit       = model.ScopeTypeEnumType("activePowerLimit")
+	lpcKeyFailsafeConsumptionLimit = "failsafeConsumptionActivePowerLimit"
+	lpcKeyFailsafeDurationMinimum  = "failsafeDurationMinimum"
+)
+
+// EEBusLPC is the controllable system of the EEBus Limitation of Power Consumption (LPC) use case.
+// The grid operator's control box writes the loadpoints' total active power limit (§14a EnWG).
+// If the control box's heartbeat is missing, the failsafe limit applies for the failsafe duration.
+type EEBusLPC struct {
+	mu  sync.Mutex
+	log *util.Logger
+
+	failsafeLimit    float64
+	failsafeDuration time.Duration
+	heartbeatTimeout time.Duration
+
+	limit       float64   // active power limit in W
+	limitActive bool      // active power limit enabled
+	limitEnd    time.Time // active power limit expiry, zero if unlimited
+	heartbeat   time.Time // last control box heartbeat
+	failsafe    time.Time // failsafe state start, zero if not in failsafe
+}
+
+// NewEEBusLPC creates the LPC controllable system and registers the control box's SKI
+func NewEEBusLPC(eebus *EEBus, other map[string]interface{}) (*EEBusLPC, error) {
+	cc := struct {
+		Ski              string
+		FailsafeLimit    float64
+		FailsafeDuration time.Duration
+		HeartbeatTimeout time.Duration
+	}{
+		FailsafeLimit:    lpcDefaultFailsafeLimit,
+		FailsafeDuration: lpcDefaultFailsafeDuration,
+		HeartbeatTimeout: lpcDefaultHeartbeatTimeout,
+	}
+
+	if err := util.DecodeOther(other, &cc); err != nil {
+		return nil, err
+	}
+
+	if cc.Ski == "" {
+		return nil, errors.New("lpc: missing ski")
+	}
+
+	if cc.FailsafeLimit <= 0 {
+		return nil, fmt.Errorf("lpc: invalid failsafe limit: %.0fW", cc.FailsafeLimit)
+	}
+
+	c := &EEBusLPC{
+		log:              util.NewLogger("lpc"),
+		failsafeLimit:    cc.FailsafeLimit,
+		failsafeDuration: cc.FailsafeDuration,
+		heartbeatTimeout: cc.HeartbeatTimeout,
+		heartbeat:        time.Now(), // init state, failsafe applies if control box doesn't connect within heartbeat timeout
+	}
+
+	eebus.Register(cc.Ski, c.onConnect, c.onDisconnect)
+
+	return c, nil
+}
+
+func (c *EEBusLPC) onConnect(ski string, conn ship.Conn) error {
+	c.log.DEBUG.Println("control box connected:", ski)
+
+	cc := communication.NewConnectionController(c.log.TRACE, conn, c.device(EEBUSDetails))
+
+	return cc.Boot()
+}
+
+func (c *EEBusLPC) onDisconnect(ski string) {
+	c.log.DEBUG.Println("control box disconnected:", ski)
+}
+
+// device creates the local device exposing the controllable system's features
+func (c *EEBusLPC) device(details communication.ManufacturerDetails) spine.Device {
+	localBrandName := model.DeviceClassificationStringType(details.BrandName)
+	localDeviceName := model.DeviceClassificationStringType(details.DeviceName)
+	localDeviceCode := model.DeviceClassificationStringType(details.DeviceCode)
+
+	operationState := model.DeviceDiagnosisOperatingStateType(model.DeviceDiagnosisOperatingStateEnumTypeNormalOperation)
+
+	dev := &spine.DeviceImpl{
+		Address: model.AddressDeviceType(details.DeviceAddress),
+		Type:    model.DeviceTypeType(model.DeviceTypeEnumTypeEnergyManagementSystem),
+	}
+
+	eid := entity.Numerator([]uint{0})
+
+	{
+		e := entity.DeviceInformation()
+		e.SetAddress(eid())
+		dev.Add(e)
+	}
+	{
+		e := &spine.EntityImpl{
+			Type: model.EntityTypeType(model.EntityTypeEnumTypeCEM),
+		}
+		e.SetAddress(eid())
+		e.SetManufacturerData(model.DeviceClassificationManufacturerDataType{
+			DeviceName: &localDeviceName,
+			DeviceCode: &localDeviceCode,
+			BrandName:  &localBrandName,
+			VendorName: &localBrandName,
+		})
+		e.SetOperationState(operationState)
+
+		fid := entity.FeatureNumerator(1)
+		for _, f := range []spine.Feature{
+			newLPCHeartbeatClient(c),
+			feature.NewDeviceDiagnosisServer(),
+			newLPCLoadControlServer(c),
+			newLPCDeviceConfigurationServer(c),
+		} {
+			f.SetID(fid())
+			e.Add(f)
+		}
+
+		dev.Add(e)
+	}
+
+	return dev
+}
+
+// PowerLimit implements the core.PowerLimiter interface
+func (c *EEBusLPC) PowerLimit() (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.powerLimit(time.Now())
+}
+
+func (c *EEBusLPC) powerLimit(now time.Time) (float64, bool) {
+	// heartbeat missing
+	if now.Sub(c.heartbeat) > c.heartbeatTimeout {
+		if c.failsafe.IsZero() {
+			c.log.WARN.Printf("heartbeat missing, failsafe limit: %.0fW", c.failsafeLimit)
+			c.failsafe = now
+		}
+
+		// operate unlimited once failsafe duration is over
+		if now.Sub(c.failsafe) < c.failsafeDuration {
+			return c.failsafeLimit, true
+		}
+
+		return 0, false
+	}
+
+	if !c.limitEnd.IsZero() && now.After(c.limitEnd) {
+		c.limitActive = false
+		c.limitEnd = time.Time{}
+	}
+
+	return c.limit, c.limitActive
+}
+
+// heartbeatReceived updates the control box's heartbeat and ends failsafe state
+func (c *EEBusLPC) heartbeatReceived() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.failsafe.IsZero() {
+		c.log.WARN.Println("heartbeat restored")
+		c.failsafe = time.Time{}
+	}
+
+	c.heartbeat = time.Now()
+}
+
+// setLimit applies the active power limit written by the control box
+func (c *EEBusLPC) setLimit(limit float64, active bool, duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.log.DEBUG.Printf("active power limit: %.0fW (active: %t, duration: %v)", limit, active, duration)
+
+	c.limit = limit
+	c.limitActive = active
+	c.limitEnd = time.Time{}
+
+	if active && duration > 0 {
+		c.limitEnd = time.Now().Add(duration)
+	}
+}
+
+// limitData returns the active power limit dataset
+func (c *EEBusLPC) limitData() model.LoadControlLimitDataType {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := model.LoadControlLimitIdType(lpcLimitID)
+	changeable := true
+	active := c.limitActive
+
+	return model.LoadControlLimitDataType{
+		LimitId:           &id,
+		IsLimitChangeable: &changeable,
+		IsLimitActive:     &active,
+		Value:             model.NewScaledNumberType(c.limit),
+	}
+}
+
+// setFailsafe applies the failsafe configuration written by the control box
+func (c *EEBusLPC) setFailsafe(limit *float64, duration *time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if duration != nil {
+		if *duration < lpcMinFailsafeDuration || *duration > lpcMaxFailsafeDuration {
+			return fmt.Errorf("invalid failsafe duration: %v", *duration)
+		}
+
+		c.failsafeDuration = *duration
+		c.log.DEBUG.Println("failsafe duration:", c.failsafeDuration)
+	}
+
+	if limit != nil {
+		if *limit <= 0 {
+			return fmt.Errorf("invalid failsafe limit: %.0fW", *limit)
+		}
+
+		c.failsafeLimit = *limit
+		c.log.DEBUG.Printf("failsafe limit: %.0fW", c.failsafeLimit)
+	}
+
+	return nil
+}
+
+// failsafeData returns the failsafe configuration datasets
+func (c *EEBusLPC) failsafeData() []model.DeviceConfigurationKeyValueDataType {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limitID := model.DeviceConfigurationKeyIdType(lpcFailsafeLimitKeyID)
+	durationID := model.DeviceConfigurationKeyIdType(lpcFailsafeDurationKeyID)
+	duration := fmt.Sprintf("PT%dS", int(c.failsafeDuration.Seconds()))
+	changeable := true
+
+	return []model.DeviceConfigurationKeyValueDataType{
+		{
+			KeyId:             &limitID,
+			Value:             &model.DeviceConfigurationKeyValueValueType{ScaledNumber: model.NewScaledNumberType(c.failsafeLimit)},
+			IsValueChangeable: &changeable,
+		},
+		{
+			KeyId:             &durationID,
+			Value:             &model.DeviceConfigurationKeyValueValueType{Duration: &duration},
+			IsValueChangeable: &changeable,
+		},
+	}
+}
+
+// lpcHeartbeatClient receives the control box's heartbeat
+type lpcHeartbeatClient struct {
+	*spine.FeatureImpl
+	lpc *EEBusLPC
+}
+
+func newLPCHeartbeatClient(lpc *EEBusLPC) spine.Feature {
+	return &lpcHeartbeatClient{
+		FeatureImpl: &spine.FeatureImpl{
+			Type: model.FeatureTypeEnumTypeDeviceDiagnosis,
+			Role: model.RoleTypeClient,
+		},
+		lpc: lpc,
+	}
+}
+
+func (f *lpcHeartbeatClient) Handle(ctrl spine.Context, rf model.FeatureAddressType, op model.CmdClassifierType, cmd model.CmdType, isPartialForCmd bool) error {
+	switch {
+	case cmd.DeviceDiagnosisHeartbeatData != nil:
+		if op != model.CmdClassifierTypeReply && op != model.CmdClassifierTypeNotify {
+			return fmt.Errorf("lpc: DeviceDiagnosisHeartbeatData CmdClassifierType not implemented: %s", op)
+		}
+
+		f.lpc.heartbeatReceived()
+		return nil
+
+	case cmd.DeviceDiagnosisStateData != nil:
+		return nil
+
+	case cmd.ResultData != nil:
+		return f.HandleResultData(ctrl, op)
+
+	default:
+		return errors.New("lpc: DeviceDiagnosis CmdType not implemented")
+	}
+}
+
+// ServerFound subscribes to the control box's heartbeat
+func (f *lpcHeartbeatClient) ServerFound(ctrl spine.Context, rf spine.Feature) error {
+	if !rf.SupportForFunctionAvailable(model.FunctionEnumTypeDeviceDiagnosisHeartbeatData) {
+		return nil
+	}
+
+	if err := ctrl.Subscribe(f, rf, model.FeatureTypeType(f.Type)); err != nil {
+		return err
+	}
+
+	res := []model.CmdType{{
+		DeviceDiagnosisHeartbeatData: &model.DeviceDiagnosisHeartbeatDataType{},
+	}}
+
+	_, err := ctrl.Request(model.CmdClassifierTypeRead, *spine.FeatureAddressType(f), *spine.FeatureAddressType(rf), true, res)
+	return err
+}
+
+// lpcLoadControlServer provides the active power limit
+type lpcLoadControlServer struct {
+	*spine.FeatureImpl
+	lpc *EEBusLPC
+}
+
+func newLPCLoadControlServer(lpc *EEBusLPC) spine.Feature {
+	f := &lpcLoadControlServer{
+		FeatureImpl: &spine.FeatureImpl{
+			Type: model.FeatureTypeEnumTypeLoadControl,
+			Role: model.RoleTypeServer,
+		},
+		lpc: lpc,
+	}
+
+	f.Add(model.FunctionEnumTypeLoadControlLimitDescriptionListData, true, false)
+	f.Add(model.FunctionEnumTypeLoadControlLimitListData, true, true)
+
+	return f
+}
+
+func (f *lpcLoadControlServer) readLimitDescription(ctrl spine.Context) error {
+	id := model.LoadControlLimitIdType(lpcLimitID)
+	limitType := model.LoadControlLimitTypeType(model.LoadControlLimitTypeEnumTypeMaxvaluelimit)
+	category := model.LoadControlCategoryType(model.LoadControlCategoryEnumTypeObligation)
+	direction := model.EnergyDirectionType(model.EnergyDirectionEnumTypeConsume)
+	unit := model.UnitOfMeasurementType(model.UnitOfMeasurementEnumTypeW)
+	scope := model.ScopeTypeType(lpcScopeActivePowerLimit)
+
+	res := model.CmdType{
+		LoadControlLimitDescriptionListData: &model.LoadControlLimitDescriptionListDataType{
+			LoadControlLimitDescriptionData: []model.LoadControlLimitDescriptionDataType{{
+				LimitId:        &id,
+				LimitType:      &limitType,
+				LimitCategory:  &category,
+				LimitDirection: &direction,
+				Unit:           &unit,
+				ScopeType:      &scope,
+			}},
+		},
+	}
+
+	return ctrl.Reply(model.CmdClassifierTypeReply, res)
+}
+
+func (f *lpcLoadControlServer) readLimit(ctrl spine.Context) error {
+	res := model.CmdType{
+		LoadControlLimitListData: &model.LoadControlLimitListDataType{
+			LoadControlLimitData: []model.LoadControlLimitDataType{f.lpc.limitData()},
+		},
+	}
+
+	return ctrl.Reply(model.CmdClassifierTypeReply, res)
+}
+
+func (f *lpcLoadControlServer) writeLimit(data model.LoadControlLimitListDataType) error {
+	for _, item := range data.LoadControlLimitData {
+		if item.LimitId == nil || *item.LimitId != lpcLimitID {
+			continue
+		}
+
+		active := item.IsLimitActive != nil && *item.IsLimitActive
+
+		var limit float64
+		if item.Value != nil {
+			limit = item.Value.GetValue()
+		}
+
+		var duration time.Duration
+		if item.TimePeriod != nil && item.TimePeriod.EndTime != nil {
+			var err error
+			if duration, err = iso8601.ParseDuration(*item.TimePeriod.EndTime); err != nil {
+				return fmt.Errorf("lpc: invalid limit duration: %w", err)
+			}
+		}
+
+		f.lpc.setLimit(limit, active, duration)
+		return nil
+	}
+
+	return errors.New("lpc: unknown limit")
+}
+
+func (f *lpcLoadControlServer) Handle(ctrl spine.Context, rf model.FeatureAddressType, op model.CmdClassifierType, cmd model.CmdType, isPartialForCmd bool) error {
+	switch {
+	case cmd.LoadControlLimitDescriptionListData != nil:
+		if op != model.CmdClassifierTypeRead {
+			return fmt.Errorf("lpc: LoadControlLimitDescriptionListData CmdClassifierType not implemented: %s", op)
+		}
+
+		return f.readLimitDescription(ctrl)
+
+	case cmd.LoadControlLimitListData != nil:
+		switch op {
+		case model.CmdClassifierTypeRead:
+			return f.readLimit(ctrl)
+		case model.CmdClassifierTypeWrite:
+			return f.writeLimit(*cmd.LoadControlLimitListData)
+		default:
+			return fmt.Errorf("lpc: LoadControlLimitListData CmdClassifierType not implemented: %s", op)
+		}
+
+	case cmd.ResultData != nil:
+		return f.HandleResultData(ctrl, op)
+
+	default:
+		return errors.New("lpc: LoadControl CmdType not implemented")
+	}
+}
+
+// lpcDeviceConfigurationServer provides the failsafe configuration
+type lpcDeviceConfigurationServer struct {
+	*spine.FeatureImpl
+	lpc *EEBusLPC
+}
+
+func newLPCDeviceConfigurationServer(lpc *EEBusLPC) spine.Feature {
+	f := &lpcDeviceConfigurationServer{
+		FeatureImpl: &spine.FeatureImpl{
+			Type: model.FeatureTypeEnumTypeDeviceConfiguration,
+			Role: model.RoleTypeServer,
+		},
+		lpc: lpc,
+	}
+
+	f.Add(model.FunctionEnumTypeDeviceConfigurationKeyValueDescriptionListData, true, false)
+	f.Add(model.FunctionEnumTypeDeviceConfigurationKeyValueListData, true, true)
+
+	return f
+}
+
+func (f *lpcDeviceConfigurationServer) readDescription(ctrl spine.Context) error {
+	limitID := model.DeviceConfigurationKeyIdType(lpcFailsafeLimitKeyID)
+	limitName := lpcKeyFailsafeConsumptionLimit
+	limitType := model.DeviceConfigurationKeyValueTypeTypeScalednumber
+	limitUnit := string(model.UnitOfMeasurementEnumTypeW)
+
+	durationID := model.DeviceConfigurationKeyIdType(lpcFailsafeDurationKeyID)
+	durationName := lpcKeyFailsafeDurationMinimum
+	durationType := model.DeviceConfigurationKeyValueTypeTypeDuration
+
+	res := model.CmdType{
+		DeviceConfigurationKeyValueDescriptionListData: &model.DeviceConfigurationKeyValueDescriptionListDataType{
+			DeviceConfigurationKeyValueDescriptionData: []model.DeviceConfigurationKeyValueDescriptionDataType{
+				{KeyId: &limitID, KeyName: &limitName, ValueType: &limitType, Unit: &limitUnit},
+				{KeyId: &durationID, KeyName: &durationName, ValueType: &durationType},
+			},
+		},
+	}
+
+	return ctrl.Reply(model.CmdClassifierTypeReply, res)
+}
+
+func (f *lpcDeviceConfigurationServer) readValues(ctrl spine.Context) error {
+	res := model.CmdType{
+		DeviceConfigurationKeyValueListData: &model.DeviceConfigurationKeyValueListDataType{
+			DeviceConfigurationKeyValueData: f.lpc.failsafeData(),
+		},
+	}
+
+	return ctrl.Reply(model.CmdClassifierTypeReply, res)
+}
+
+func (f *lpcDeviceConfigurationServer) writeValues(data model.DeviceConfigurationKeyValueListDataType) error {
+	var limit *float64
+	var duration *time.Duration
+
+	for _, item := range data.DeviceConfigurationKeyValueData {
+		if item.KeyId == nil || item.Value == nil {
+			continue
+		}
+
+		switch *item.KeyId {
+		case lpcFailsafeLimitKeyID:
+			if item.Value.ScaledNumber != nil {
+				value := item.Value.ScaledNumber.GetValue()
+				limit = &value
+			}
+
+		case lpcFailsafeDurationKeyID:
+			if item.Value.Duration != nil {
+				value, err := iso8601.ParseDuration(*item.Value.Duration)
+				if err != nil {
+					return fmt.Errorf("lpc: invalid failsafe duration: %w", err)
+				}
+				duration = &value
+			}
+		}
+	}
+
+	return f.lpc.setFailsafe(limit, duration)
+}
+
+func (f *lpcDeviceConfigurationServer) Handle(ctrl spine.Context, rf model.FeatureAddressType, op model.CmdClassifierType, cmd model.CmdType, isPartialForCmd bool) error {
+	switch {
+	case cmd.DeviceConfigurationKeyValueDescriptionListData != nil:
+		if op != model.CmdClassifierTypeRead {
+			return fmt.Errorf("lpc: DeviceConfigurationKeyValueDescriptionListData CmdClassifierType not implemented: %s", op)
+		}
+
+		return f.readDescription(ctrl)
+
+	case cmd.DeviceConfigurationKeyValueListData != nil:
+		switch op {
+		case model.CmdClassifierTypeRead:
+			return f.readValues(ctrl)
+		case model.CmdClassifierTypeWrite:
+			return f.writeValues(*cmd.DeviceConfigurationKeyValueListData)
+		default:
+			return fmt.Errorf("lpc: DeviceConfigurationKeyValueListData CmdClassifierType not implemented: %s", op)
+		}
+
+	case cmd.ResultData != nil:
+		return f.HandleResultData(ctrl, op)
+
+	default:
+		return errors.New("lpc: DeviceConfiguration CmdType not implemented")
+	}
+}
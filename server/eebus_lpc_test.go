@@ -0,0 +1,94 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/util"
+)
+
+func TestLPCPowerLimit(t *testing.T) {
+	now := time.Now()
+
+	c := &EEBusLPC{
+		log:              util.NewLogger("foo"),
+		failsafeLimit:    4200,
+		failsafeDuration: 2 * time.Hour,
+		heartbeatTimeout: 2 * time.Minute,
+		heartbeat:        now,
+	}
+
+	if _, active := c.powerLimit(now); active {
+		t.Error("unexpected limit")
+	}
+
+	c.setLimit(6000, true, 0)
+
+	if limit, active := c.powerLimit(now.Add(time.Minute)); !active || limit != 6000 {
+		t.Errorf("expected 6000W, got %.0fW (%t)", limit, active)
+	}
+
+	// heartbeat missing
+	if limit, active := c.powerLimit(now.Add(3 * time.Minute)); !active || limit != 4200 {
+		t.Errorf("expected failsafe 4200W, got %.0fW (%t)", limit, active)
+	}
+
+	// failsafe duration over
+	if _, active := c.powerLimit(now.Add(3*time.Minute + 2*time.Hour)); active {
+		t.Error("unexpected limit after failsafe duration")
+	}
+
+	// heartbeat restored
+	c.heartbeatReceived()
+
+	if limit, active := c.powerLimit(time.Now()); !active || limit != 6000 {
+		t.Errorf("expected 6000W, got %.0fW (%t)", limit, active)
+	}
+
+	// limit expired
+	c.setLimit(6000, true, time.Minute)
+
+	if _, active := c.powerLimit(time.Now().Add(90 * time.Second)); active {
+		t.Error("unexpected limit after expiry")
+	}
+}
+
+func TestLPCInit(t *testing.T) {
+	start := time.Now()
+
+	c := &EEBusLPC{
+		log:              util.NewLogger("foo"),
+		failsafeLimit:    4200,
+		failsafeDuration: 2 * time.Hour,
+		heartbeatTimeout: 2 * time.Minute,
+		heartbeat:        start,
+	}
+
+	if _, active := c.powerLimit(start.Add(time.Minute)); active {
+		t.Error("unexpected limit within heartbeat timeout")
+	}
+
+	// control box not connected after startup
+	if limit, active := c.powerLimit(start.Add(3 * time.Minute)); !active || limit != 4200 {
+		t.Errorf("expected failsafe 4200W, got %.0fW (%t)", limit, active)
+	}
+}
+
+func TestLPCFailsafe(t *testing.T) {
+	c := &EEBusLPC{log: util.NewLogger("foo")}
+
+	l := 0.0
+	if err := c.setFailsafe(&l, nil); err == nil {
+		t.Error("expected invalid failsafe limit")
+	}
+
+	d := time.Hour
+	if err := c.setFailsafe(nil, &d); err == nil {
+		t.Error("expected invalid failsafe duration")
+	}
+
+	d = 3 * time.Hour
+	if err := c.setFailsafe(nil, &d); err != nil || c.failsafeDuration != d {
+		t.Errorf("unexpected failsafe duration %v: %v", c.failsafeDuration, err)
+	}
+}
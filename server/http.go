@@ -19,6 +19,7 @@ type route struct {
 	Methods     []string
 	Pattern     string
 	HandlerFunc http.HandlerFunc
+	Summary     string
 }
 
 // routeLogger traces matched routes including their executing time
@@ -41,22 +42,38 @@ type HTTPd struct {
 }
 
 // NewHTTPd creates HTTP server with configured routes for loadpoint
-func NewHTTPd(url string, site site.API, hub *SocketHub, cache *util.Cache) *HTTPd {
+func NewHTTPd(url string, site site.API, hub *SocketHub, cache *util.Cache, auth *Auth) *HTTPd {
+	spec := newOpenAPISpec()
+
 	routes := map[string]route{
-		"health": {[]string{"GET"}, "/health", healthHandler(site)},
-		"state":  {[]string{"GET"}, "/state", stateHandler(cache)},
+		"health":     {[]string{"GET"}, "/health", healthHandler(site), "Health check"},
+		"state":      {[]string{"GET"}, "/state", stateHandler(cache), "Complete site and loadpoint state"},
+		"site":       {[]string{"GET"}, "/site", siteHandler(cache), "Site state"},
+		"updateSite": {[]string{"PATCH", "OPTIONS"}, "/site", updateSiteHandler(site), "Update site settings"},
+		"savings":    {[]string{"GET"}, "/savings", savingsHandler(), "Persisted savings per period"},
+		"sessions":   {[]string{"GET"}, "/sessions", sessionsHandler(), "Charging sessions, csv with ?format=csv"},
+		"openapi":    {[]string{"GET"}, "/openapi.json", openAPIHandler(spec), "OpenAPI description"},
 	}
 
 	router := mux.NewRouter().StrictSlash(true)
 
 	// websocket
-	router.HandleFunc("/ws", socketHandler(hub))
+	router.Handle("/ws", auth.Handler(socketHandler(hub)))
+
+	// login
+	router.HandleFunc("/login", loginPageHandler())
+
+	authAPI := router.PathPrefix("/api/auth").Subrouter()
+	authAPI.Use(jsonHandler)
+	authAPI.HandleFunc("/login", auth.loginHandler()).Methods("POST")
+	authAPI.HandleFunc("/logout", auth.logoutHandler()).Methods("POST")
+	authAPI.HandleFunc("/status", auth.statusHandler()).Methods("GET")
 
 	// static - individual handlers per root and folders
 	static := router.PathPrefix("/").Subrouter()
 	static.Use(handlers.CompressHandler)
 
-	static.HandleFunc("/", indexHandler(site))
+	static.Handle("/", auth.uiHandler(indexHandler(site)))
 	for _, dir := range []string{"css", "js", "ico"} {
 		static.PathPrefix("/" + dir).Handler(http.FileServer(http.FS(Assets)))
 	}
@@ -67,33 +84,45 @@ func NewHTTPd(url string, site site.API, hub *SocketHub, cache *util.Cache) *HTT
 	api.Use(handlers.CompressHandler)
 	api.Use(handlers.CORS(
 		handlers.AllowedHeaders([]string{
+			"Authorization",
 			"Content-Type",
 		}),
 	))
+	api.Use(auth.Handler)
 
 	// site api
-	for _, r := range routes {
+	for id, r := range routes {
 		api.Methods(r.Methods...).Path(r.Pattern).Handler(r.HandlerFunc)
+		spec.add("site", id, "", r)
 	}
 
 	// loadpoint api
 	for id, lp := range site.LoadPoints() {
-		lpAPI := api.PathPrefix(fmt.Sprintf("/loadpoints/%d", id)).Subrouter()
+		prefix := fmt.Sprintf("/loadpoints/%d", id)
 
 		routes := map[string]route{
-			"mode":          {[]string{"POST", "OPTIONS"}, "/mode/{value:[a-z]+}", chargeModeHandler(lp)},
-			"targetsoc":     {[]string{"POST", "OPTIONS"}, "/targetsoc/{value:[0-9]+}", targetSoCHandler(lp)},
-			"minsoc":        {[]string{"POST", "OPTIONS"}, "/minsoc/{value:[0-9]+}", minSoCHandler(lp)},
-			"mincurrent":    {[]string{"POST", "OPTIONS"}, "/mincurrent/{value:[0-9]+}", minCurrentHandler(lp)},
-			"maxcurrent":    {[]string{"POST", "OPTIONS"}, "/maxcurrent/{value:[0-9]+}", maxCurrentHandler(lp)},
-			"phases":        {[]string{"POST", "OPTIONS"}, "/phases/{value:[0-9]+}", phasesHandler(lp)},
-			"targetcharge":  {[]string{"POST", "OPTIONS"}, "/targetcharge/{soc:[0-9]+}/{time:[0-9TZ:-]+}", targetChargeHandler(lp)},
-			"targetcharge2": {[]string{"DELETE", "OPTIONS"}, "/targetcharge", targetChargeRemoveHandler(lp)},
-			"remotedemand":  {[]string{"POST", "OPTIONS"}, "/remotedemand/{demand:[a-z]+}/{source::[0-9a-zA-Z_-]+}", remoteDemandHandler(lp)},
+			"loadpoint":          {[]string{"GET"}, "", loadpointHandler(cache, id), "Loadpoint state"},
+			"updateLoadpoint":    {[]string{"PATCH", "OPTIONS"}, "", updateLoadpointHandler(lp), "Update multiple loadpoint settings"},
+			"mode":               {[]string{"POST", "OPTIONS"}, "/mode/{value:[a-z]+}", chargeModeHandler(lp), "Set charge mode"},
+			"targetsoc":          {[]string{"POST", "OPTIONS"}, "/targetsoc/{value:[0-9]+}", targetSoCHandler(lp), "Set target soc"},
+			"minsoc":             {[]string{"POST", "OPTIONS"}, "/minsoc/{value:[0-9]+}", minSoCHandler(lp), "Set minimum soc"},
+			"mincurrent":         {[]string{"POST", "OPTIONS"}, "/mincurrent/{value:[0-9]+}", minCurrentHandler(lp), "Set minimum current"},
+			"maxcurrent":         {[]string{"POST", "OPTIONS"}, "/maxcurrent/{value:[0-9]+}", maxCurrentHandler(lp), "Set maximum current"},
+			"phases":             {[]string{"POST", "OPTIONS"}, "/phases/{value:[0-9]+}", phasesHandler(lp), "Set enabled phases"},
+			"targetcharge":       {[]string{"POST", "OPTIONS"}, "/targetcharge/{soc:[0-9]+}/{time:[0-9TZ:-]+}", targetChargeHandler(lp), "Set target charge"},
+			"removeTargetcharge": {[]string{"DELETE", "OPTIONS"}, "/targetcharge", targetChargeRemoveHandler(lp), "Remove target charge"},
+			"plans":              {[]string{"GET"}, "/plans", plansHandler(lp), "Recurring charging plans"},
+			"setPlans":           {[]string{"POST", "OPTIONS"}, "/plans", setPlansHandler(lp), "Replace recurring charging plans"},
+			"remotedemand":       {[]string{"POST", "OPTIONS"}, "/remotedemand/{demand:[a-z]+}/{source::[0-9a-zA-Z_-]+}", remoteDemandHandler(lp), "Set remote demand"},
 		}
 
-		for _, r := range routes {
-			lpAPI.Methods(r.Methods...).Path(r.Pattern).Handler(r.HandlerFunc)
+		for name, r := range routes {
+			api.Methods(r.Methods...).Path(prefix + r.Pattern).Handler(r.HandlerFunc)
+
+			// loadpoints share their description
+			if id == 0 {
+				spec.add("loadpoint", name, "/loadpoints/{id:[0-9]+}", r)
+			}
 		}
 	}
 
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
@@ -12,7 +14,10 @@ import (
 
 	"github.com/evcc-io/evcc/api"
 	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/plan"
 	"github.com/evcc-io/evcc/core/site"
+	"github.com/evcc-io/evcc/core/store"
+	"github.com/evcc-io/evcc/server/db"
 	"github.com/evcc-io/evcc/util"
 	"github.com/gorilla/mux"
 )
@@ -90,6 +95,236 @@ func stateHandler(cache *util.Cache) http.HandlerFunc {
 	}
 }
 
+// siteHandler returns the site state without loadpoints
+func siteHandler(cache *util.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := cache.State()
+		for _, k := range []string{"availableVersion", "releaseNotes", "loadpoints"} {
+			delete(res, k)
+		}
+		jsonResult(w, res)
+	}
+}
+
+// siteSettings are the site settings updated by PATCH
+type siteSettings struct {
+	PrioritySoC *float64 `json:"prioritySoC"`
+}
+
+// updateSiteHandler updates site settings from json body
+func updateSiteHandler(site site.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req siteSettings
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.PrioritySoC != nil {
+			if err := site.SetPrioritySoC(*req.PrioritySoC); err != nil {
+				jsonError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		jsonResult(w, req)
+	}
+}
+
+// loadpointHandler returns the loadpoint state
+func loadpointHandler(cache *util.Cache, id int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lps, _ := cache.State()["loadpoints"].([]map[string]interface{})
+		if id >= len(lps) || lps[id] == nil {
+			jsonError(w, http.StatusNotFound, errors.New("loadpoint not available"))
+			return
+		}
+		jsonResult(w, lps[id])
+	}
+}
+
+// loadpointSettings are the loadpoint settings updated by PATCH
+type loadpointSettings struct {
+	Mode         *api.ChargeMode `json:"mode,omitempty"`
+	TargetSoC    *int            `json:"targetSoC,omitempty"`
+	MinSoC       *int            `json:"minSoC,omitempty"`
+	MinCurrent   *float64        `json:"minCurrent,omitempty"`
+	MaxCurrent   *float64        `json:"maxCurrent,omitempty"`
+	Phases       *int            `json:"phases,omitempty"`
+	TargetCharge *targetCharge   `json:"targetCharge,omitempty"`
+}
+
+// validate checks the settings against each other and the loadpoint's current settings
+func (s loadpointSettings) validate(lp loadpoint.API) error {
+	if s.Mode != nil && *s.Mode == api.ModeEmpty {
+		return errors.New("invalid charge mode")
+	}
+
+	if s.TargetSoC != nil && (*s.TargetSoC < 0 || *s.TargetSoC > 100) {
+		return fmt.Errorf("invalid target soc: %d", *s.TargetSoC)
+	}
+
+	if s.MinSoC != nil && (*s.MinSoC < 0 || *s.MinSoC > 100) {
+		return fmt.Errorf("invalid min soc: %d", *s.MinSoC)
+	}
+
+	if s.MinCurrent != nil || s.MaxCurrent != nil {
+		minCurrent, maxCurrent := lp.GetMinCurrent(), lp.GetMaxCurrent()
+		if s.MinCurrent != nil {
+			minCurrent = *s.MinCurrent
+		}
+		if s.MaxCurrent != nil {
+			maxCurrent = *s.MaxCurrent
+		}
+
+		if minCurrent <= 0 || maxCurrent < minCurrent {
+			return fmt.Errorf("invalid currents: %.3gA..%.3gA", minCurrent, maxCurrent)
+		}
+	}
+
+	if s.Phases != nil && *s.Phases != 1 && *s.Phases != 3 {
+		return fmt.Errorf("invalid number of phases: %d", *s.Phases)
+	}
+
+	if s.TargetCharge != nil && (s.TargetCharge.SoC <= 0 || s.TargetCharge.SoC > 100) {
+		return fmt.Errorf("invalid target charge soc: %d", s.TargetCharge.SoC)
+	}
+
+	return nil
+}
+
+// updateLoadpointHandler updates multiple loadpoint settings from json body
+func updateLoadpointHandler(lp loadpoint.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req loadpointSettings
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := req.validate(lp); err != nil {
+			jsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// phase switching may still fail with the charger, apply it first
+		if req.Phases != nil {
+			if err := lp.SetPhases(*req.Phases); err != nil {
+				jsonError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		if req.Mode != nil {
+			lp.SetMode(*req.Mode)
+		}
+		if req.MinSoC != nil {
+			lp.SetMinSoC(*req.MinSoC)
+		}
+		if req.TargetSoC != nil {
+			lp.SetTargetSoC(*req.TargetSoC)
+		}
+		if req.MinCurrent != nil {
+			lp.SetMinCurrent(*req.MinCurrent)
+		}
+		if req.MaxCurrent != nil {
+			lp.SetMaxCurrent(*req.MaxCurrent)
+		}
+		if req.TargetCharge != nil {
+			lp.SetTargetCharge(req.TargetCharge.Time, req.TargetCharge.SoC)
+		}
+
+		mode, targetSoC, minSoC := lp.GetMode(), lp.GetTargetSoC(), lp.GetMinSoC()
+		minCurrent, maxCurrent, phases := lp.GetMinCurrent(), lp.GetMaxCurrent(), lp.GetPhases()
+
+		jsonResult(w, loadpointSettings{
+			Mode:       &mode,
+			TargetSoC:  &targetSoC,
+			MinSoC:     &minSoC,
+			MinCurrent: &minCurrent,
+			MaxCurrent: &maxCurrent,
+			Phases:     &phases,
+		})
+	}
+}
+
+// savingsHandler returns persisted savings per day, month or year
+func savingsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if db.Instance == nil {
+			jsonError(w, http.StatusNotFound, errors.New("database not configured"))
+			return
+		}
+
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = db.PeriodMonth
+		}
+
+		res, err := db.Instance.Savings(period)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		jsonResult(w, res)
+	}
+}
+
+// sessionsHandler returns the charging sessions as JSON or CSV
+func sessionsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if db.Instance == nil {
+			jsonError(w, http.StatusNotFound, errors.New("database not configured"))
+			return
+		}
+
+		res, err := db.Instance.Sessions()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="sessions.csv"`)
+			sessionsCSV(w, res)
+			return
+		}
+
+		jsonResult(w, res)
+	}
+}
+
+// sessionsCSV writes the charging sessions as CSV
+func sessionsCSV(w http.ResponseWriter, sessions []store.Session) {
+	ww := csv.NewWriter(w)
+
+	_ = ww.Write([]string{
+		"loadpoint", "vehicle", "identifier", "created", "finished",
+		"meterStart", "meterStop", "chargedEnergy", "solarPercentage", "price",
+	})
+
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+
+	for _, s := range sessions {
+		_ = ww.Write([]string{
+			s.LoadPoint, s.Vehicle, s.Identifier,
+			s.Created.Format(time.RFC3339), s.Finished.Format(time.RFC3339),
+			f(s.MeterStart), f(s.MeterStop), f(s.ChargedEnergy), f(s.SolarPercentage), f(s.Price),
+		})
+	}
+
+	ww.Flush()
+
+	if err := ww.Error(); err != nil {
+		log.ERROR.Printf("httpd: failed to encode CSV: %v", err)
+	}
+}
+
 // chargeModeHandler updates charge mode
 func chargeModeHandler(lp loadpoint.API) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -194,6 +429,12 @@ func phasesHandler(lp loadpoint.API) http.HandlerFunc {
 	}
 }
 
+// remoteDemand is the remote demand set by source
+type remoteDemand struct {
+	Demand loadpoint.RemoteDemand `json:"demand"`
+	Source string                 `json:"source"`
+}
+
 // remoteDemandHandler updates minimum soc
 func remoteDemandHandler(lp loadpoint.API) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -208,10 +449,7 @@ func remoteDemandHandler(lp loadpoint.API) http.HandlerFunc {
 
 		lp.RemoteControl(source, demand)
 
-		res := struct {
-			Demand loadpoint.RemoteDemand `json:"demand"`
-			Source string                 `json:"source"`
-		}{
+		res := remoteDemand{
 			Source: source,
 			Demand: demand,
 		}
@@ -230,6 +468,12 @@ func timezone() *time.Location {
 	return loc
 }
 
+// targetCharge is the target soc to reach at given time
+type targetCharge struct {
+	SoC  int       `json:"soc"`
+	Time time.Time `json:"time"`
+}
+
 // targetChargeHandler updates target soc
 func targetChargeHandler(loadpoint loadpoint.API) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -253,11 +497,8 @@ func targetChargeHandler(loadpoint loadpoint.API) http.HandlerFunc {
 
 		loadpoint.SetTargetCharge(timeV, int(socV))
 
-		res := struct {
-			SoC  int64     `json:"soc"`
-			Time time.Time `json:"time"`
-		}{
-			SoC:  socV,
+		res := targetCharge{
+			SoC:  int(socV),
 			Time: timeV,
 		}
 
@@ -274,6 +515,31 @@ func targetChargeRemoveHandler(loadpoint loadpoint.API) http.HandlerFunc {
 	}
 }
 
+// plansHandler returns recurring charging plans
+func plansHandler(lp loadpoint.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jsonResult(w, lp.GetPlans())
+	}
+}
+
+// setPlansHandler replaces recurring charging plans from json body
+func setPlansHandler(lp loadpoint.API) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var plans []plan.Plan
+		if err := json.NewDecoder(r.Body).Decode(&plans); err != nil {
+			jsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := lp.SetPlans(plans); err != nil {
+			jsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		jsonResult(w, lp.GetPlans())
+	}
+}
+
 // socketHandler attaches websocket handler to uri
 func socketHandler(hub *SocketHub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -1,16 +1,28 @@
 package server
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/evcc-io/evcc/core/loadpoint"
 	"github.com/evcc-io/evcc/util"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
 	influxlog "github.com/influxdata/influxdb-client-go/v2/log"
 )
 
+const (
+	influxPrecision     = time.Second
+	influxReplayBatch   = 5000
+	influxMaxBufferSize = 64 << 20 // 64MB
+)
+
 // InfluxConfig is the influx db configuration
 type InfluxConfig struct {
 	URL      string
@@ -19,7 +31,10 @@ type InfluxConfig struct {
 	Org      string
 	User     string
 	Password string
-	Interval time.Duration
+	Interval time.Duration     // write interval
+	Tags     map[string]string // additional tags added to all points
+	Buffer   string            // file for buffering points while the database is unreachable
+	Sessions bool              // write charging session summaries as session measurement
 }
 
 // Influx is a influx publisher
@@ -29,28 +44,43 @@ type Influx struct {
 	client   influxdb2.Client
 	org      string
 	database string
+	interval time.Duration
+	tags     map[string]string
+	buffer   string
+	sessions bool
+	points   []*write.Point
 }
 
 // NewInfluxClient creates new publisher for influx
-func NewInfluxClient(url, token, org, user, password, database string) *Influx {
+func NewInfluxClient(conf InfluxConfig) *Influx {
 	log := util.NewLogger("influx")
 
 	// InfluxDB v1 compatibility
-	if token == "" && user != "" {
-		token = fmt.Sprintf("%s:%s", user, password)
+	token := conf.Token
+	if token == "" && conf.User != "" {
+		token = fmt.Sprintf("%s:%s", conf.User, conf.Password)
 	}
 
-	options := influxdb2.DefaultOptions().SetPrecision(time.Second)
-	client := influxdb2.NewClientWithOptions(url, token, options)
+	options := influxdb2.DefaultOptions().SetPrecision(influxPrecision)
+	client := influxdb2.NewClientWithOptions(conf.URL, token, options)
 
 	// handle error logging in writer
 	influxlog.Log = nil
 
+	interval := conf.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+
 	return &Influx{
 		log:      log,
 		client:   client,
-		org:      org,
-		database: database,
+		org:      conf.Org,
+		database: conf.Database,
+		interval: interval,
+		tags:     conf.Tags,
+		buffer:   conf.Buffer,
+		sessions: conf.Sessions,
 	}
 }
 
@@ -72,19 +102,208 @@ func (m *Influx) supportedType(p util.Param) bool {
 	}
 }
 
+// addPoint queues point for writing
+func (m *Influx) addPoint(measurement string, tags map[string]string, fields map[string]interface{}, ts time.Time) {
+	for k, v := range m.tags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	m.log.TRACE.Printf("write %s=%v (%v)", measurement, fields, tags)
+
+	m.Lock()
+	m.points = append(m.points, influxdb2.NewPoint(measurement, tags, fields, ts))
+	m.Unlock()
+}
+
+// flush writes queued points. If the database is unreachable, points are appended to the buffer file.
+// After a successful write the buffer file is replayed.
+func (m *Influx) flush(writer api.WriteAPIBlocking) {
+	m.Lock()
+	points := m.points
+	m.points = nil
+	m.Unlock()
+
+	if len(points) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := writer.WritePoint(ctx, points...); err != nil {
+		m.log.ERROR.Println(err)
+
+		if m.buffer != "" {
+			if err := m.bufferPoints(points); err != nil {
+				m.log.ERROR.Printf("buffer: %v", err)
+			}
+		}
+
+		return
+	}
+
+	if m.buffer != "" {
+		if err := m.replay(writer); err != nil {
+			m.log.ERROR.Printf("replay: %v", err)
+		}
+	}
+}
+
+// bufferPoints appends points as line protocol to the buffer file
+func (m *Influx) bufferPoints(points []*write.Point) error {
+	if fi, err := os.Stat(m.buffer); err == nil && fi.Size() > influxMaxBufferSize {
+		return fmt.Errorf("size limit exceeded, dropping %d points", len(points))
+	}
+
+	f, err := os.OpenFile(m.buffer, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, p := range points {
+		_, _ = w.WriteString(write.PointToLineProtocol(p, influxPrecision))
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// replay writes buffered points and removes the buffer file on success.
+// Points written twice are idempotent as series and timestamp are identical.
+func (m *Influx) replay(writer api.WriteAPIBlocking) error {
+	f, err := os.Open(m.buffer)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	var total int
+	writeLines := func(lines []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		total += len(lines)
+		return writer.WriteRecord(ctx, lines...)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+
+		if len(lines) == influxReplayBatch {
+			if err := writeLines(lines); err != nil {
+				return err
+			}
+			lines = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(lines) > 0 {
+		if err := writeLines(lines); err != nil {
+			return err
+		}
+	}
+
+	m.log.INFO.Printf("replayed %d buffered points", total)
+
+	return os.Remove(m.buffer)
+}
+
+// influxSession tracks a loadpoint's charging session for writing summaries
+type influxSession struct {
+	connected bool
+	start     time.Time
+	vehicle   string
+	energy    float64
+	duration  time.Duration
+	soc       float64
+}
+
+// updateSession tracks session values and writes a summary when the vehicle disconnects
+func (m *Influx) updateSession(s *influxSession, tags map[string]string, param util.Param) {
+	switch param.Key {
+	case "connected":
+		connected, _ := param.Val.(bool)
+
+		if connected && !s.connected {
+			*s = influxSession{connected: true, start: time.Now()}
+		}
+
+		if !connected && s.connected {
+			s.connected = false
+
+			if s.energy > 0 {
+				tags["vehicle"] = s.vehicle
+
+				m.addPoint("session", tags, map[string]interface{}{
+					"chargedEnergy":  s.energy,
+					"chargeDuration": s.duration.Seconds(),
+					"duration":       time.Since(s.start).Seconds(),
+					"vehicleSoC":     s.soc,
+				}, time.Now())
+			}
+		}
+
+	case "chargedEnergy":
+		if f, ok := param.Val.(float64); ok && f > 0 {
+			s.energy = f
+			s.vehicle = tags["vehicle"]
+		}
+
+	case "chargeDuration":
+		if d, ok := param.Val.(time.Duration); ok && d > 0 {
+			s.duration = d
+		}
+
+	case "vehicleSoC":
+		if f, ok := param.Val.(float64); ok {
+			s.soc = f
+		}
+	}
+}
+
 // Run Influx publisher
 func (m *Influx) Run(loadPoints []loadpoint.API, in <-chan util.Param) {
-	writer := m.client.WriteAPI(m.org, m.database)
+	writer := m.client.WriteAPIBlocking(m.org, m.database)
 
-	// log errors
+	// write queued points
+	done, flushed := make(chan struct{}), make(chan struct{})
 	go func() {
-		for err := range writer.Errors() {
-			m.log.ERROR.Println(err)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.flush(writer)
+			case <-done:
+				m.flush(writer)
+				close(flushed)
+				return
+			}
 		}
 	}()
 
-	// track active vehicle per loadpoint
+	// track active vehicle and session per loadpoint
 	vehicles := make(map[int]string)
+	sessions := make(map[int]*influxSession)
 
 	// add points to batch for async writing
 	for param := range in {
@@ -96,6 +315,22 @@ func (m *Influx) Run(loadPoints []loadpoint.API, in <-chan util.Param) {
 			}
 		}
 
+		// session summaries
+		if m.sessions && param.LoadPoint != nil {
+			s, ok := sessions[*param.LoadPoint]
+			if !ok {
+				s = new(influxSession)
+				sessions[*param.LoadPoint] = s
+			}
+
+			tags := map[string]string{
+				"loadpoint": loadPoints[*param.LoadPoint].Name(),
+				"vehicle":   vehicles[*param.LoadPoint],
+			}
+
+			m.updateSession(s, tags, param)
+		}
+
 		if !m.supportedType(param) {
 			continue
 		}
@@ -128,11 +363,12 @@ func (m *Influx) Run(loadPoints []loadpoint.API, in <-chan util.Param) {
 
 		fields["value"] = val
 
-		// write asynchronously
-		m.log.TRACE.Printf("write %s=%v (%v)", param.Key, param.Val, tags)
-		p := influxdb2.NewPoint(param.Key, tags, fields, time.Now())
-		writer.WritePoint(p)
+		m.addPoint(param.Key, tags, fields, time.Now())
 	}
 
+	// write remaining points
+	close(done)
+	<-flushed
+
 	m.client.Close()
 }
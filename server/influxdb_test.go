@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/util"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+func TestInfluxSession(t *testing.T) {
+	m := &Influx{
+		log:  util.NewLogger("foo"),
+		tags: map[string]string{"site": "home"},
+	}
+
+	s := new(influxSession)
+	tags := func() map[string]string {
+		return map[string]string{"loadpoint": "garage", "vehicle": "car"}
+	}
+
+	for _, p := range []util.Param{
+		{Key: "connected", Val: true},
+		{Key: "chargedEnergy", Val: 5000.0},
+		{Key: "chargeDuration", Val: time.Hour},
+		{Key: "vehicleSoC", Val: 80.0},
+	} {
+		m.updateSession(s, tags(), p)
+	}
+
+	if len(m.points) != 0 {
+		t.Fatalf("unexpected session point before disconnect")
+	}
+
+	m.updateSession(s, tags(), util.Param{Key: "connected", Val: false})
+
+	if len(m.points) != 1 {
+		t.Fatalf("expected session point, got %d", len(m.points))
+	}
+
+	p := m.points[0]
+	if p.Name() != "session" {
+		t.Errorf("unexpected measurement %s", p.Name())
+	}
+
+	expectedTags := map[string]string{"loadpoint": "garage", "vehicle": "car", "site": "home"}
+	for _, tag := range p.TagList() {
+		if expectedTags[tag.Key] != tag.Value {
+			t.Errorf("unexpected tag %s=%s", tag.Key, tag.Value)
+		}
+	}
+
+	for _, f := range p.FieldList() {
+		if f.Key == "chargedEnergy" && f.Value != 5000.0 {
+			t.Errorf("unexpected charged energy %v", f.Value)
+		}
+	}
+
+	// no empty sessions
+	m.updateSession(s, tags(), util.Param{Key: "connected", Val: true})
+	m.updateSession(s, tags(), util.Param{Key: "connected", Val: false})
+
+	if len(m.points) != 1 {
+		t.Errorf("unexpected empty session point")
+	}
+}
+
+// influxWriter records written points and lines or fails if offline
+type influxWriter struct {
+	offline bool
+	points  []*write.Point
+	lines   []string
+}
+
+func (w *influxWriter) WriteRecord(_ context.Context, line ...string) error {
+	if w.offline {
+		return errors.New("offline")
+	}
+	w.lines = append(w.lines, line...)
+	return nil
+}
+
+func (w *influxWriter) WritePoint(_ context.Context, point ...*write.Point) error {
+	if w.offline {
+		return errors.New("offline")
+	}
+	w.points = append(w.points, point...)
+	return nil
+}
+
+func TestInfluxBuffer(t *testing.T) {
+	buffer := filepath.Join(t.TempDir(), "influx.buffer")
+
+	m := &Influx{
+		log:    util.NewLogger("foo"),
+		buffer: buffer,
+	}
+
+	ts := time.Unix(1600000000, 0)
+	writer := &influxWriter{offline: true}
+
+	// database unreachable, points are buffered
+	for i := 0; i < 2; i++ {
+		m.points = []*write.Point{influxdb2.NewPoint("gridPower", map[string]string{"site": "home"}, map[string]interface{}{"value": float64(i)}, ts.Add(time.Duration(i)*time.Second))}
+		m.flush(writer)
+	}
+
+	if len(writer.points) != 0 || len(m.points) != 0 {
+		t.Fatalf("unexpected points written %d or queued %d", len(writer.points), len(m.points))
+	}
+
+	if _, err := os.Stat(buffer); err != nil {
+		t.Fatalf("missing buffer: %v", err)
+	}
+
+	// database reachable, buffered points are replayed after the current points
+	writer.offline = false
+	m.points = []*write.Point{influxdb2.NewPoint("gridPower", map[string]string{"site": "home"}, map[string]interface{}{"value": 2.0}, ts.Add(2*time.Second))}
+	m.flush(writer)
+
+	if len(writer.points) != 1 {
+		t.Errorf("expected current point written, got %d", len(writer.points))
+	}
+
+	expected := []string{
+		"gridPower,site=home value=0 1600000000",
+		"gridPower,site=home value=1 1600000001",
+	}
+
+	if len(writer.lines) != len(expected) {
+		t.Fatalf("expected %d replayed lines, got %v", len(expected), writer.lines)
+	}
+
+	for i, line := range expected {
+		if writer.lines[i] != line {
+			t.Errorf("expected %q, got %q", line, writer.lines[i])
+		}
+	}
+
+	if _, err := os.Stat(buffer); !os.IsNotExist(err) {
+		t.Errorf("expected buffer removed: %v", err)
+	}
+
+	// nothing to replay
+	m.points = []*write.Point{influxdb2.NewPoint("gridPower", nil, map[string]interface{}{"value": 3.0}, ts)}
+	m.flush(writer)
+
+	if len(writer.lines) != len(expected) {
+		t.Errorf("unexpected replay: %v", writer.lines)
+	}
+}
@@ -1,12 +1,17 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/evcc-io/evcc/api"
 	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/plan"
 	"github.com/evcc-io/evcc/core/site"
 	"github.com/evcc-io/evcc/provider/mqtt"
 	"github.com/evcc-io/evcc/util"
@@ -14,15 +19,23 @@ import (
 
 // MQTT is the MQTT server. It uses the MQTT client for publishing.
 type MQTT struct {
-	Handler *mqtt.Client
-	root    string
+	Handler   *mqtt.Client
+	root      string
+	discovery string
+
+	mu       sync.Mutex
+	retained map[string]string // retained values for republishing after reconnect
+	setters  map[string]bool   // topics accepting commands
 }
 
-// NewMQTT creates MQTT server
-func NewMQTT(root string) *MQTT {
+// NewMQTT creates MQTT server. Home Assistant discovery is published below the discovery prefix if not empty.
+func NewMQTT(root, discovery string) *MQTT {
 	return &MQTT{
-		Handler: mqtt.Instance,
-		root:    root,
+		Handler:   mqtt.Instance,
+		root:      root,
+		discovery: discovery,
+		retained:  make(map[string]string),
+		setters:   make(map[string]bool),
 	}
 }
 
@@ -34,6 +47,10 @@ func (m *MQTT) encode(v interface{}) string {
 	case time.Duration:
 		// must be before stringer to convert to seconds instead of string
 		s = fmt.Sprintf("%d", int64(val.Seconds()))
+	case api.Rates, []plan.Plan:
+		if b, err := json.Marshal(val); err == nil {
+			s = string(b)
+		}
 	case fmt.Stringer, string:
 		s = fmt.Sprintf("%s", val)
 	case float64:
@@ -45,10 +62,31 @@ func (m *MQTT) encode(v interface{}) string {
 }
 
 func (m *MQTT) publishSingleValue(topic string, retained bool, payload interface{}) {
-	token := m.Handler.Client.Publish(topic, m.Handler.Qos, retained, m.encode(payload))
+	s := m.encode(payload)
+
+	if retained {
+		m.mu.Lock()
+		m.retained[topic] = s
+		m.mu.Unlock()
+	}
+
+	token := m.Handler.Client.Publish(topic, m.Handler.Qos, retained, s)
 	go m.Handler.WaitForToken(token)
 }
 
+// republish publishes all retained values again, e.g. after the broker restarted without persistence
+func (m *MQTT) republish() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	log.DEBUG.Printf("mqtt: republishing %d values", len(m.retained))
+
+	for topic, s := range m.retained {
+		token := m.Handler.Client.Publish(topic, m.Handler.Qos, true, s)
+		go m.Handler.WaitForToken(token)
+	}
+}
+
 func (m *MQTT) publish(topic string, retained bool, payload interface{}) {
 	if slice, ok := payload.([]float64); ok && len(slice) == 3 {
 		// publish phase values
@@ -65,48 +103,146 @@ func (m *MQTT) publish(topic string, retained bool, payload interface{}) {
 	m.publishSingleValue(topic, retained, payload)
 }
 
+// listenSetter handles <topic>/set commands and acknowledges them on <topic>/result with the error if any
+func (m *MQTT) listenSetter(topic string, set func(string) error) {
+	m.mu.Lock()
+	m.setters[topic] = true
+	m.mu.Unlock()
+
+	m.Handler.ListenSetter(topic+"/set", func(payload string) {
+		res := struct {
+			Payload string `json:"payload"`
+			Error   string `json:"error,omitempty"`
+		}{
+			Payload: payload,
+		}
+
+		if err := set(payload); err != nil {
+			log.ERROR.Printf("%s: %v", topic, err)
+			res.Error = err.Error()
+		}
+
+		if b, err := json.Marshal(res); err == nil {
+			m.publishSingleValue(topic+"/result", false, string(b))
+		}
+	})
+}
+
+// parseInt parses integer payloads, accepting float notation as sent by some home automation systems
+func parseInt(payload string) (int, error) {
+	f, err := strconv.ParseFloat(payload, 64)
+	return int(f), err
+}
+
 func (m *MQTT) listenSetters(topic string, apiHandler loadpoint.API) {
-	m.Handler.ListenSetter(topic+"/mode/set", func(payload string) {
-		apiHandler.SetMode(api.ChargeMode(payload))
+	m.listenSetter(topic+"/mode", func(payload string) error {
+		mode, err := api.ChargeModeString(payload)
+		if err == nil {
+			apiHandler.SetMode(mode)
+		}
+		return err
 	})
-	m.Handler.ListenSetter(topic+"/minSoC/set", func(payload string) {
-		if soc, err := strconv.Atoi(payload); err == nil {
+	m.listenSetter(topic+"/minSoC", func(payload string) error {
+		soc, err := parseInt(payload)
+		if err == nil {
 			apiHandler.SetMinSoC(soc)
 		}
+		return err
 	})
-	m.Handler.ListenSetter(topic+"/targetSoC/set", func(payload string) {
-		if soc, err := strconv.Atoi(payload); err == nil {
+	m.listenSetter(topic+"/targetSoC", func(payload string) error {
+		soc, err := parseInt(payload)
+		if err == nil {
 			apiHandler.SetTargetSoC(soc)
 		}
+		return err
 	})
-	m.Handler.ListenSetter(topic+"/minCurrent/set", func(payload string) {
-		if current, err := strconv.ParseFloat(payload, 64); err == nil {
+	m.listenSetter(topic+"/minCurrent", func(payload string) error {
+		current, err := strconv.ParseFloat(payload, 64)
+		if err == nil {
 			apiHandler.SetMinCurrent(current)
 		}
+		return err
 	})
-	m.Handler.ListenSetter(topic+"/maxCurrent/set", func(payload string) {
-		if current, err := strconv.ParseFloat(payload, 64); err == nil {
+	m.listenSetter(topic+"/maxCurrent", func(payload string) error {
+		current, err := strconv.ParseFloat(payload, 64)
+		if err == nil {
 			apiHandler.SetMaxCurrent(current)
 		}
+		return err
+	})
+	m.listenSetter(topic+"/phases", func(payload string) error {
+		phases, err := parseInt(payload)
+		if err == nil {
+			err = apiHandler.SetPhases(phases)
+		}
+		return err
+	})
+	m.listenSetter(topic+"/targetCharge", func(payload string) error {
+		// {"soc":80,"time":"2022-01-01T07:00:00+01:00"}, empty object or null removes the target
+		var target struct {
+			SoC  int       `json:"soc"`
+			Time time.Time `json:"time"`
+		}
+
+		if err := json.Unmarshal([]byte(payload), &target); err != nil {
+			return err
+		}
+
+		if target.Time.IsZero() != (target.SoC == 0) {
+			return errors.New("target charge requires soc and time")
+		}
+
+		apiHandler.SetTargetCharge(target.Time, target.SoC)
+		return nil
+	})
+	m.listenSetter(topic+"/remoteDemand", func(payload string) error {
+		// hard, soft, enable or {"demand":"hard","source":"..."}
+		req := struct {
+			Demand string `json:"demand"`
+			Source string `json:"source"`
+		}{
+			Demand: payload,
+			Source: "mqtt",
+		}
+
+		if strings.HasPrefix(payload, "{") {
+			if err := json.Unmarshal([]byte(payload), &req); err != nil {
+				return err
+			}
+		}
+
+		demand, err := loadpoint.RemoteDemandString(req.Demand)
+		if err == nil {
+			apiHandler.RemoteControl(req.Source, demand)
+		}
+		return err
 	})
-	m.Handler.ListenSetter(topic+"/phases/set", func(payload string) {
-		if phases, err := strconv.Atoi(payload); err == nil {
-			_ = apiHandler.SetPhases(phases)
+	m.listenSetter(topic+"/plans", func(payload string) error {
+		var plans []plan.Plan
+		err := json.Unmarshal([]byte(payload), &plans)
+		if err == nil {
+			err = apiHandler.SetPlans(plans)
 		}
+		return err
 	})
 }
 
 // Run starts the MQTT publisher for the MQTT API
 func (m *MQTT) Run(site site.API, in <-chan util.Param) {
-	// alive
+	// alive, used as home assistant availability
 	topic := fmt.Sprintf("%s/status", m.root)
 	m.publish(topic, true, "online")
 
+	// restore values after reconnect
+	m.Handler.OnConnect(m.republish)
+
 	// site setters
-	m.Handler.ListenSetter(fmt.Sprintf("%s/site/prioritySoC/set", m.root), func(payload string) {
-		if soc, err := strconv.Atoi(payload); err == nil {
-			_ = site.SetPrioritySoC(float64(soc))
+	m.listenSetter(fmt.Sprintf("%s/site/prioritySoC", m.root), func(payload string) error {
+		soc, err := strconv.ParseFloat(payload, 64)
+		if err == nil {
+			err = site.SetPrioritySoC(soc)
 		}
+		return err
 	})
 
 	// number of loadpoints
@@ -119,6 +255,11 @@ func (m *MQTT) Run(site site.API, in <-chan util.Param) {
 		m.listenSetters(topic, lp)
 	}
 
+	// home assistant discovery, requires status and setters
+	if m.discovery != "" {
+		m.publishHomeAssistant(site)
+	}
+
 	// alive indicator
 	updated := time.Now().Unix()
 	m.publish(fmt.Sprintf("%s/updated", m.root), true, updated)
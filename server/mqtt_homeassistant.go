@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/site"
+)
+
+// haEntity describes a Home Assistant entity published via MQTT discovery
+type haEntity struct {
+	component   string // sensor, binary_sensor, select, number
+	key         string // published key
+	name        string
+	unit        string
+	deviceClass string
+	stateClass  string
+	options     []string // select options
+	min, max    float64  // number range
+	step        float64
+}
+
+var haSiteEntities = []haEntity{
+	{component: "sensor", key: "gridPower", name: "Grid power", unit: "W", deviceClass: "power", stateClass: "measurement"},
+	{component: "sensor", key: "pvPower", name: "PV power", unit: "W", deviceClass: "power", stateClass: "measurement"},
+	{component: "sensor", key: "batteryPower", name: "Battery power", unit: "W", deviceClass: "power", stateClass: "measurement"},
+	{component: "sensor", key: "homePower", name: "Home power", unit: "W", deviceClass: "power", stateClass: "measurement"},
+	{component: "sensor", key: "batterySoC", name: "Battery soc", unit: "%", deviceClass: "battery", stateClass: "measurement"},
+	{component: "sensor", key: "gridEnergy", name: "Grid energy", unit: "kWh", deviceClass: "energy", stateClass: "total_increasing"},
+	{component: "sensor", key: "tariffGrid", name: "Grid price", stateClass: "measurement"},
+	{component: "number", key: "prioritySoC", name: "Battery priority soc", unit: "%", min: 0, max: 100, step: 5},
+}
+
+var haLoadpointEntities = []haEntity{
+	{component: "sensor", key: "chargePower", name: "Charge power", unit: "W", deviceClass: "power", stateClass: "measurement"},
+	{component: "sensor", key: "chargedEnergy", name: "Charged energy", unit: "Wh", deviceClass: "energy", stateClass: "total_increasing"},
+	{component: "sensor", key: "chargeCurrent", name: "Charge current", unit: "A", deviceClass: "current", stateClass: "measurement"},
+	{component: "sensor", key: "chargeDuration", name: "Charge duration", unit: "s", deviceClass: "duration"},
+	{component: "sensor", key: "chargeRemainingDuration", name: "Charge remaining duration", unit: "s", deviceClass: "duration"},
+	{component: "sensor", key: "activePhases", name: "Active phases", stateClass: "measurement"},
+	{component: "sensor", key: "vehicleSoC", name: "Vehicle soc", unit: "%", deviceClass: "battery", stateClass: "measurement"},
+	{component: "sensor", key: "vehicleRange", name: "Vehicle range", unit: "km", deviceClass: "distance", stateClass: "measurement"},
+	{component: "sensor", key: "vehicleOdometer", name: "Vehicle odometer", unit: "km", deviceClass: "distance", stateClass: "total_increasing"},
+	{component: "sensor", key: "vehicleTitle", name: "Vehicle"},
+	{component: "binary_sensor", key: "connected", name: "Connected", deviceClass: "plug"},
+	{component: "binary_sensor", key: "charging", name: "Charging", deviceClass: "battery_charging"},
+	{component: "binary_sensor", key: "enabled", name: "Enabled"},
+	{component: "select", key: "mode", name: "Mode", options: []string{
+		string(api.ModeOff), string(api.ModeNow), string(api.ModeMinPV), string(api.ModePV),
+	}},
+	{component: "number", key: "minSoC", name: "Minimum soc", unit: "%", min: 0, max: 100, step: 5},
+	{component: "number", key: "targetSoC", name: "Target soc", unit: "%", min: 0, max: 100, step: 5},
+	{component: "number", key: "minCurrent", name: "Minimum current", unit: "A", min: 6, max: 32, step: 1},
+	{component: "number", key: "maxCurrent", name: "Maximum current", unit: "A", min: 6, max: 32, step: 1},
+	{component: "select", key: "phases", name: "Phases", options: []string{"1", "3"}},
+}
+
+// haObjectID converts the topic into a valid Home Assistant object id
+func haObjectID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// haSetter checks if commands are accepted for the entity published below topic
+func (m *MQTT) haSetter(e haEntity, topic string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setters[fmt.Sprintf("%s/%s", topic, e.key)]
+}
+
+// haConfig creates the discovery payload for an entity published below topic
+func (m *MQTT) haConfig(e haEntity, topic, prefix string, device map[string]interface{}) map[string]interface{} {
+	id := haObjectID(fmt.Sprintf("%s_%s", strings.TrimPrefix(topic, "/"), e.key))
+
+	res := map[string]interface{}{
+		"name":                  prefix + e.name,
+		"unique_id":             id,
+		"object_id":             id,
+		"state_topic":           fmt.Sprintf("%s/%s", topic, e.key),
+		"availability_topic":    fmt.Sprintf("%s/status", m.root),
+		"payload_available":     "online",
+		"payload_not_available": "offline",
+		"device":                device,
+	}
+
+	if e.unit != "" {
+		res["unit_of_measurement"] = e.unit
+	}
+	if e.deviceClass != "" {
+		res["device_class"] = e.deviceClass
+	}
+	if e.stateClass != "" {
+		res["state_class"] = e.stateClass
+	}
+
+	switch e.component {
+	case "binary_sensor":
+		res["payload_on"] = "true"
+		res["payload_off"] = "false"
+	case "select":
+		res["command_topic"] = fmt.Sprintf("%s/%s/set", topic, e.key)
+		res["options"] = e.options
+	case "number":
+		res["command_topic"] = fmt.Sprintf("%s/%s/set", topic, e.key)
+		res["min"] = e.min
+		res["max"] = e.max
+		res["step"] = e.step
+	}
+
+	return res
+}
+
+// publishHomeAssistant publishes retained Home Assistant discovery configurations for site and loadpoints
+func (m *MQTT) publishHomeAssistant(site site.API) {
+	device := map[string]interface{}{
+		"identifiers":  []string{haObjectID(m.root)},
+		"name":         "evcc",
+		"manufacturer": "evcc.io",
+		"sw_version":   Version,
+	}
+
+	publish := func(e haEntity, topic, prefix string) {
+		// controls without command listener are read-only
+		if (e.component == "select" || e.component == "number") && !m.haSetter(e, topic) {
+			log.DEBUG.Printf("homeassistant: %s/%s not settable", topic, e.key)
+			e.component = "sensor"
+		}
+
+		conf := m.haConfig(e, topic, prefix, device)
+
+		b, err := json.Marshal(conf)
+		if err != nil {
+			log.ERROR.Printf("homeassistant: %v", err)
+			return
+		}
+
+		m.publishSingleValue(fmt.Sprintf("%s/%s/%s/%s/config", m.discovery, e.component, haObjectID(m.root), conf["object_id"]), true, string(b))
+	}
+
+	for _, e := range haSiteEntities {
+		publish(e, fmt.Sprintf("%s/site", m.root), "")
+	}
+
+	for id, lp := range site.LoadPoints() {
+		prefix := lp.Name()
+		if prefix == "" {
+			prefix = fmt.Sprintf("Loadpoint %d", id+1)
+		}
+
+		for _, e := range haLoadpointEntities {
+			publish(e, fmt.Sprintf("%s/loadpoints/%d", m.root, id+1), prefix+" ")
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHomeAssistantConfig(t *testing.T) {
+	m := &MQTT{root: "evcc", discovery: "homeassistant"}
+
+	number := haEntity{component: "number", key: "targetSoC", name: "Target soc", unit: "%", max: 100, step: 5}
+	conf := m.haConfig(number, "evcc/loadpoints/1", "Garage ", nil)
+
+	assert.Equal(t, "Garage Target soc", conf["name"])
+	assert.Equal(t, "evcc_loadpoints_1_targetSoC", conf["unique_id"])
+	assert.Equal(t, "evcc/loadpoints/1/targetSoC", conf["state_topic"])
+	assert.Equal(t, "evcc/loadpoints/1/targetSoC/set", conf["command_topic"])
+	assert.Equal(t, "evcc/status", conf["availability_topic"])
+	assert.Equal(t, "online", conf["payload_available"])
+	assert.Equal(t, "%", conf["unit_of_measurement"])
+	assert.NotContains(t, conf, "device_class")
+
+	sensor := haEntity{component: "binary_sensor", key: "connected", name: "Connected", deviceClass: "plug"}
+	conf = m.haConfig(sensor, "evcc/loadpoints/1", "", nil)
+
+	assert.Equal(t, "true", conf["payload_on"])
+	assert.NotContains(t, conf, "command_topic")
+
+	assert.False(t, m.haSetter(number, "evcc/loadpoints/1"))
+	m.setters = map[string]bool{"evcc/loadpoints/1/targetSoC": true}
+	assert.True(t, m.haSetter(number, "evcc/loadpoints/1"))
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/plan"
+	"github.com/evcc-io/evcc/core/store"
+)
+
+// openAPIVarRE matches mux path variables with optional pattern like {value:[0-9]+}
+var openAPIVarRE = regexp.MustCompile(`\{(\w+)(?::+([^}]+))?\}`)
+
+// openAPIBody are an operation's json request and response types
+type openAPIBody struct {
+	Request, Response interface{}
+}
+
+// openAPIBodies maps operation ids to their request and response types. Each route requires an entry.
+var openAPIBodies = map[string]openAPIBody{
+	"health":             {nil, nil},
+	"state":              {nil, map[string]interface{}{}},
+	"site":               {nil, map[string]interface{}{}},
+	"openapi":            {nil, nil},
+	"loadpoint":          {nil, map[string]interface{}{}},
+	"updateSite":         {siteSettings{}, siteSettings{}},
+	"savings":            {nil, []store.Savings{}},
+	"sessions":           {nil, []store.Session{}},
+	"updateLoadpoint":    {loadpointSettings{}, loadpointSettings{}},
+	"mode":               {nil, api.ModeEmpty},
+	"targetsoc":          {nil, 0},
+	"minsoc":             {nil, 0},
+	"mincurrent":         {nil, 0.0},
+	"maxcurrent":         {nil, 0.0},
+	"phases":             {nil, 0},
+	"targetcharge":       {nil, targetCharge{}},
+	"removeTargetcharge": {nil, struct{}{}},
+	"plans":              {nil, []plan.Plan{}},
+	"setPlans":           {[]plan.Plan{}, []plan.Plan{}},
+	"remotedemand":       {nil, remoteDemand{}},
+}
+
+// openAPIEnums are the allowed values of string types
+var openAPIEnums = map[reflect.Type][]string{
+	reflect.TypeOf(api.ModeEmpty): {
+		api.ModeOff.String(), api.ModeNow.String(), api.ModeMinPV.String(), api.ModePV.String(),
+	},
+	reflect.TypeOf(loadpoint.RemoteEnable): {
+		string(loadpoint.RemoteEnable), string(loadpoint.RemoteHardDisable), string(loadpoint.RemoteSoftDisable),
+	},
+}
+
+// openAPISchema derives the json schema of the given type from its json tags
+func openAPISchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return openAPISchema(t.Elem())
+
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+
+	case reflect.String:
+		res := map[string]interface{}{"type": "string"}
+		if enum, ok := openAPIEnums[t]; ok {
+			res["enum"] = enum
+		}
+		return res
+
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem())}
+
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": openAPISchema(t.Elem())}
+
+	case reflect.Struct:
+		props := make(map[string]interface{})
+		var required []string
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			if tag[0] == "-" {
+				continue
+			}
+
+			name := tag[0]
+			if name == "" {
+				name = f.Name
+			}
+
+			props[name] = openAPISchema(f.Type)
+
+			// pointers and omitempty fields are optional
+			if f.Type.Kind() != reflect.Ptr && !(len(tag) > 1 && tag[1] == "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		res := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			res["required"] = required
+		}
+		return res
+	}
+
+	return map[string]interface{}{}
+}
+
+// openAPIContent returns the json content description of the given schema
+func openAPIContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": schema,
+		},
+	}
+}
+
+// openAPIResult returns the schema of a json result wrapping the given type
+func openAPIResult(res interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	if res != nil {
+		schema = openAPISchema(reflect.TypeOf(res))
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"result": schema},
+		"required":   []string{"result"},
+	}
+}
+
+// openAPIError is the schema of a json error
+var openAPIError = map[string]interface{}{
+	"type":       "object",
+	"properties": map[string]interface{}{"error": map[string]string{"type": "string"}},
+	"required":   []string{"error"},
+}
+
+type openAPIParameter struct {
+	Name     string            `json:"name"`
+	In       string            `json:"in"`
+	Required bool              `json:"required"`
+	Schema   map[string]string `json:"schema"`
+}
+
+type openAPIOperation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []openAPIParameter     `json:"parameters,omitempty"`
+	RequestBody map[string]interface{} `json:"requestBody,omitempty"`
+	Responses   map[string]interface{} `json:"responses"`
+}
+
+// openAPISpec is an OpenAPI 3 description generated from the route tables
+type openAPISpec struct {
+	OpenAPI string                                  `json:"openapi"`
+	Info    map[string]string                       `json:"info"`
+	Servers []map[string]string                     `json:"servers"`
+	Paths   map[string]map[string]*openAPIOperation `json:"paths"`
+}
+
+func newOpenAPISpec() *openAPISpec {
+	return &openAPISpec{
+		OpenAPI: "3.0.3",
+		Info:    map[string]string{"title": "evcc", "version": Version},
+		Servers: []map[string]string{{"url": "/api"}},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+}
+
+// add adds the route's operations. Path variables are converted into parameters including their pattern.
+func (s *openAPISpec) add(tag, id, prefix string, r route) {
+	var params []openAPIParameter
+
+	path := openAPIVarRE.ReplaceAllStringFunc(prefix+r.Pattern, func(v string) string {
+		match := openAPIVarRE.FindStringSubmatch(v)
+
+		schema := map[string]string{"type": "string"}
+		if match[2] != "" {
+			schema["pattern"] = "^" + match[2] + "$"
+		}
+
+		params = append(params, openAPIParameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+
+		return "{" + match[1] + "}"
+	})
+
+	ops, ok := s.Paths[path]
+	if !ok {
+		ops = make(map[string]*openAPIOperation)
+		s.Paths[path] = ops
+	}
+
+	for _, method := range r.Methods {
+		if method == http.MethodOptions {
+			continue
+		}
+
+		body := openAPIBodies[id]
+
+		op := &openAPIOperation{
+			OperationID: id,
+			Summary:     r.Summary,
+			Tags:        []string{tag},
+			Parameters:  params,
+			Responses: map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "result",
+					"content":     openAPIContent(openAPIResult(body.Response)),
+				},
+				"400": map[string]interface{}{
+					"description": "error",
+					"content":     openAPIContent(openAPIError),
+				},
+			},
+		}
+
+		// settings are sent as json body
+		if body.Request != nil {
+			op.RequestBody = map[string]interface{}{
+				"required": true,
+				"content":  openAPIContent(openAPISchema(reflect.TypeOf(body.Request))),
+			}
+		}
+
+		ops[strings.ToLower(method)] = op
+	}
+}
+
+// openAPIHandler returns the OpenAPI description
+func openAPIHandler(spec *openAPISpec) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jsonWrite(w, spec)
+	}
+}
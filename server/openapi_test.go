@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/core/site"
+	"github.com/evcc-io/evcc/util"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	spec := newOpenAPISpec()
+
+	spec.add("loadpoint", "remotedemand", "/loadpoints/{id:[0-9]+}", route{
+		Methods: []string{"POST", "OPTIONS"},
+		Pattern: "/remotedemand/{demand:[a-z]+}/{source::[0-9a-zA-Z_-]+}",
+	})
+	spec.add("loadpoint", "updateLoadpoint", "/loadpoints/{id:[0-9]+}", route{
+		Methods: []string{"PATCH", "OPTIONS"},
+	})
+
+	ops, ok := spec.Paths["/loadpoints/{id}/remotedemand/{demand}/{source}"]
+	if !ok {
+		t.Fatalf("missing path: %v", spec.Paths)
+	}
+
+	op, ok := ops["post"]
+	if !ok || len(ops) != 1 {
+		t.Fatalf("unexpected operations: %v", ops)
+	}
+
+	if len(op.Parameters) != 3 || op.Parameters[2].Schema["pattern"] != "^[0-9a-zA-Z_-]+$" {
+		t.Errorf("unexpected parameters: %v", op.Parameters)
+	}
+
+	if op.RequestBody != nil {
+		t.Error("unexpected request body")
+	}
+
+	if op := spec.Paths["/loadpoints/{id}"]["patch"]; op == nil || op.RequestBody == nil {
+		t.Error("missing request body")
+	}
+}
+
+func TestOpenAPISchema(t *testing.T) {
+	schema := openAPISchema(reflect.TypeOf(loadpointSettings{}))
+
+	props, ok := schema["properties"].(map[string]interface{})
+	if !ok || len(props) != 7 {
+		t.Fatalf("unexpected properties: %v", schema)
+	}
+
+	if _, ok := schema["required"]; ok {
+		t.Errorf("unexpected required properties: %v", schema["required"])
+	}
+
+	if mode := props["mode"].(map[string]interface{}); !reflect.DeepEqual(mode["enum"], []string{"off", "now", "minpv", "pv"}) {
+		t.Errorf("unexpected mode: %v", mode)
+	}
+
+	tc := props["targetCharge"].(map[string]interface{})
+	if !reflect.DeepEqual(tc["required"], []string{"soc", "time"}) {
+		t.Errorf("unexpected target charge: %v", tc)
+	}
+
+	if ts := tc["properties"].(map[string]interface{})["time"]; !reflect.DeepEqual(ts, map[string]interface{}{"type": "string", "format": "date-time"}) {
+		t.Errorf("unexpected time: %v", ts)
+	}
+}
+
+// openAPISite is a site with a single loadpoint for route registration
+type openAPISite struct {
+	site.API
+}
+
+func (s openAPISite) LoadPoints() []loadpoint.API {
+	return []loadpoint.API{nil}
+}
+
+func TestOpenAPIBodies(t *testing.T) {
+	auth, err := NewAuth(AuthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpd := NewHTTPd("", openAPISite{}, NewSocketHub(), util.NewCache(), auth)
+
+	w := httptest.NewRecorder()
+	httpd.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	var spec openAPISpec
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spec.Paths) == 0 {
+		t.Fatal("missing paths")
+	}
+
+	// every registered route requires request and response types
+	for path, ops := range spec.Paths {
+		for method, op := range ops {
+			if _, ok := openAPIBodies[op.OperationID]; !ok {
+				t.Errorf("%s %s: missing body for %s", method, path, op.OperationID)
+			}
+		}
+	}
+}
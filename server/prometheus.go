@@ -0,0 +1,131 @@
+package server
+
+import (
+	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/util"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// promMetric maps a published key to a prometheus metric
+type promMetric struct {
+	name, help string
+	scale      float64 // optional conversion factor to base unit
+}
+
+var promSiteMetrics = map[string]promMetric{
+	"gridPower":    {"site_grid_power_watts", "Grid power", 0},
+	"pvPower":      {"site_pv_power_watts", "PV power", 0},
+	"batteryPower": {"site_battery_power_watts", "Battery power", 0},
+	"homePower":    {"site_home_power_watts", "Home power", 0},
+	"batterySoC":   {"site_battery_soc_percent", "Battery soc", 0},
+	"gridEnergy":   {"site_grid_energy_watthours", "Grid meter energy", 1e3},
+	"tariffGrid":   {"site_tariff_grid_price", "Grid price per kWh", 0},
+}
+
+var promLoadpointMetrics = map[string]promMetric{
+	"chargePower":   {"loadpoint_charge_power_watts", "Charge power", 0},
+	"chargedEnergy": {"loadpoint_charged_energy_watthours", "Energy charged in current session", 0},
+	"chargeCurrent": {"loadpoint_charge_current_amperes", "Charge current", 0},
+	"vehicleSoC":    {"loadpoint_vehicle_soc_percent", "Vehicle soc", 0},
+	"vehicleRange":  {"loadpoint_vehicle_range_kilometers", "Vehicle range", 0},
+}
+
+// Prometheus exports published values as prometheus metrics
+type Prometheus struct {
+	loadPoints []loadpoint.API
+	site       map[string]prometheus.Gauge
+	loadpoint  map[string]*prometheus.GaugeVec
+}
+
+// NewPrometheus creates a prometheus publisher and registers its metrics
+func NewPrometheus(loadPoints []loadpoint.API) *Prometheus {
+	return newPrometheus(prometheus.DefaultRegisterer, loadPoints)
+}
+
+func newPrometheus(reg prometheus.Registerer, loadPoints []loadpoint.API) *Prometheus {
+	m := &Prometheus{
+		loadPoints: loadPoints,
+		site:       make(map[string]prometheus.Gauge),
+		loadpoint:  make(map[string]*prometheus.GaugeVec),
+	}
+
+	for key, pm := range promSiteMetrics {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "evcc",
+			Name:      pm.name,
+			Help:      pm.help,
+		})
+
+		reg.MustRegister(g)
+		m.site[key] = g
+	}
+
+	for key, pm := range promLoadpointMetrics {
+		g := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "evcc",
+			Name:      pm.name,
+			Help:      pm.help,
+		}, []string{"loadpoint", "vehicle"})
+
+		reg.MustRegister(g)
+		m.loadpoint[key] = g
+	}
+
+	return m
+}
+
+// value converts supported types to float
+func (m *Prometheus) value(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case float64:
+		return val, true
+	default:
+		return 0, false
+	}
+}
+
+// Run Prometheus publisher
+func (m *Prometheus) Run(in <-chan util.Param) {
+	// track active vehicle per loadpoint
+	vehicles := make(map[int]string)
+
+	for p := range in {
+		if p.LoadPoint == nil {
+			if g, ok := m.site[p.Key]; ok {
+				if f, ok := m.value(p.Val); ok {
+					if scale := promSiteMetrics[p.Key].scale; scale != 0 {
+						f *= scale
+					}
+					g.Set(f)
+				}
+			}
+
+			continue
+		}
+
+		id := *p.LoadPoint
+		name := m.loadPoints[id].Name()
+
+		// vehicle changed, remove series of previous vehicle
+		if title, ok := p.Val.(string); ok && p.Key == "vehicleTitle" {
+			if prev, ok := vehicles[id]; !ok || prev != title {
+				for _, g := range m.loadpoint {
+					g.Delete(prometheus.Labels{"loadpoint": name, "vehicle": prev})
+				}
+				vehicles[id] = title
+			}
+
+			continue
+		}
+
+		if g, ok := m.loadpoint[p.Key]; ok {
+			if f, ok := m.value(p.Val); ok {
+				g.WithLabelValues(name, vehicles[id]).Set(f)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/evcc-io/evcc/core/loadpoint"
+	"github.com/evcc-io/evcc/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// promLoadpoint is a loadpoint providing its name
+type promLoadpoint struct {
+	loadpoint.API
+}
+
+func (lp promLoadpoint) Name() string {
+	return "lp1"
+}
+
+func TestPrometheus(t *testing.T) {
+	lp := 0
+
+	tc := []struct {
+		title   string
+		params  []util.Param
+		metric  string
+		vehicle string
+		value   float64
+		series  int
+	}{
+		{"site power", []util.Param{
+			{Key: "gridPower", Val: 1500.0},
+		}, "gridPower", "", 1500, 1},
+		{"site int value", []util.Param{
+			{Key: "batterySoC", Val: 80},
+		}, "batterySoC", "", 80, 1},
+		{"grid energy in Wh", []util.Param{
+			{Key: "gridEnergy", Val: 1.5},
+		}, "gridEnergy", "", 1500, 1},
+		{"unsupported type ignored", []util.Param{
+			{Key: "pvPower", Val: 1000.0},
+			{Key: "pvPower", Val: "foo"},
+		}, "pvPower", "", 1000, 1},
+		{"loadpoint value", []util.Param{
+			{LoadPoint: &lp, Key: "vehicleTitle", Val: "car"},
+			{LoadPoint: &lp, Key: "chargePower", Val: 11000.0},
+		}, "chargePower", "car", 11000, 1},
+		{"vehicle change removes previous series", []util.Param{
+			{LoadPoint: &lp, Key: "vehicleTitle", Val: "car"},
+			{LoadPoint: &lp, Key: "chargePower", Val: 11000.0},
+			{LoadPoint: &lp, Key: "vehicleTitle", Val: "other"},
+			{LoadPoint: &lp, Key: "chargePower", Val: 3700.0},
+		}, "chargePower", "other", 3700, 1},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc.title)
+
+		m := newPrometheus(prometheus.NewRegistry(), []loadpoint.API{promLoadpoint{}})
+
+		in := make(chan util.Param, len(tc.params))
+		for _, p := range tc.params {
+			in <- p
+		}
+		close(in)
+
+		m.Run(in)
+
+		var c prometheus.Collector
+		var g prometheus.Gauge
+
+		if vec, ok := m.loadpoint[tc.metric]; ok {
+			c, g = vec, vec.WithLabelValues("lp1", tc.vehicle)
+		} else {
+			c, g = m.site[tc.metric], m.site[tc.metric]
+		}
+
+		if n := testutil.CollectAndCount(c); n != tc.series {
+			t.Errorf("expected %d series, got %d", tc.series, n)
+		}
+
+		if v := testutil.ToFloat64(g); v != tc.value {
+			t.Errorf("expected %v, got %v", tc.value, v)
+		}
+	}
+}
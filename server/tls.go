@@ -0,0 +1,116 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const selfSignedValidity = 10 * 365 * 24 * time.Hour
+
+// TLSConfig is the web server's TLS configuration
+type TLSConfig struct {
+	Certificate string // certificate file
+	Key         string // private key file
+	SelfSigned  bool   // generate self-signed certificate on first start
+}
+
+// Enabled returns true if the web server should use TLS
+func (c TLSConfig) Enabled() bool {
+	return c.Certificate != "" || c.SelfSigned
+}
+
+// Files returns certificate and key file names. For self-signed certificates missing files are
+// generated, by default in the given directory.
+func (c TLSConfig) Files(dir string) (string, string, error) {
+	cert, key := c.Certificate, c.Key
+
+	if !c.SelfSigned {
+		if cert == "" || key == "" {
+			return "", "", errors.New("tls: missing certificate or key")
+		}
+		return cert, key, nil
+	}
+
+	if cert == "" {
+		cert = filepath.Join(dir, "evcc.crt")
+	}
+	if key == "" {
+		key = filepath.Join(dir, "evcc.key")
+	}
+
+	_, certErr := os.Stat(cert)
+	_, keyErr := os.Stat(key)
+
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		log.INFO.Printf("tls: creating self-signed certificate %s", cert)
+		if err := createSelfSigned(cert, key); err != nil {
+			return "", "", fmt.Errorf("tls: %w", err)
+		}
+	}
+
+	return cert, key, nil
+}
+
+// createSelfSigned creates a self-signed certificate for the host's names and addresses
+func createSelfSigned(certFile, keyFile string) error {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "evcc", Organization: []string{"evcc"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		template.DNSNames = append(template.DNSNames, hostname, hostname+".local")
+	}
+
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ip, ok := addr.(*net.IPNet); ok && !ip.IP.IsLoopback() {
+				template.IPAddresses = append(template.IPAddresses, ip.IP)
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return err
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestTLSSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := TLSConfig{SelfSigned: true}
+
+	cert, key, err := conf.Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tls.LoadX509KeyPair(cert, key); err != nil {
+		t.Fatal(err)
+	}
+
+	// existing certificate is reused
+	if cert2, key2, err := conf.Files(dir); err != nil || cert2 != cert || key2 != key {
+		t.Errorf("expected %s/%s, got %s/%s: %v", cert, key, cert2, key2, err)
+	}
+}
+
+func TestTLSMissingKey(t *testing.T) {
+	if _, _, err := (TLSConfig{Certificate: "evcc.crt"}).Files(""); err == nil {
+		t.Error("expected error")
+	}
+}
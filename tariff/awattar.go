@@ -21,7 +21,10 @@ type Awattar struct {
 	data  []awattar.PriceInfo
 }
 
-var _ api.Tariff = (*Awattar)(nil)
+var (
+	_ api.Tariff         = (*Awattar)(nil)
+	_ api.TariffForecast = (*Awattar)(nil)
+)
 
 func NewAwattar(other map[string]interface{}) (*Awattar, error) {
 	cc := struct {
@@ -81,3 +84,26 @@ func (t *Awattar) IsCheap() (bool, error) {
 	price, err := t.CurrentPrice()
 	return price <= t.cheap, err
 }
+
+// Rates implements the api.TariffForecast interface
+func (t *Awattar) Rates() (api.Rates, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	res := make(api.Rates, 0, len(t.data))
+	for _, pi := range t.data {
+		if pi.EndTimestamp.After(time.Now()) {
+			res = append(res, api.Rate{
+				Start: pi.StartTimestamp,
+				End:   pi.EndTimestamp,
+				Price: pi.Marketprice / 1000, // convert EUR/MWh to EUR/KWh
+			})
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("unable to find awattar rates")
+	}
+
+	return res, nil
+}
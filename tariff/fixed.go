@@ -1,30 +1,106 @@
 package tariff
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/tariff/fixed"
 	"github.com/evcc-io/evcc/util"
 )
 
 type Fixed struct {
 	Price float64
+	Cheap float64
+	zones []fixed.Zone
 }
 
-var _ api.Tariff = (*Fixed)(nil)
+var (
+	_ api.Tariff         = (*Fixed)(nil)
+	_ api.TariffForecast = (*Fixed)(nil)
+)
+
+// forecastHorizon is the duration covered by fixed tariff rates
+const forecastHorizon = 48 * time.Hour
 
 func NewFixed(other map[string]interface{}) (*Fixed, error) {
-	cc := Fixed{}
+	var cc struct {
+		Price float64
+		Cheap float64
+		Zones []struct {
+			Days, Hours string
+			Price       float64
+		}
+	}
 
 	if err := util.DecodeOther(other, &cc); err != nil {
 		return nil, err
 	}
 
-	return &cc, nil
+	t := &Fixed{
+		Price: cc.Price,
+		Cheap: cc.Cheap,
+	}
+
+	for i, z := range cc.Zones {
+		days, err := util.ParseDays(z.Days)
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %w", i+1, err)
+		}
+
+		hours, err := fixed.ParseHours(z.Hours)
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %w", i+1, err)
+		}
+
+		t.zones = append(t.zones, fixed.Zone{
+			Days:  days,
+			Hours: hours,
+			Price: z.Price,
+		})
+	}
+
+	return t, nil
+}
+
+// priceAt returns the price of the first matching zone or the default price
+func (t *Fixed) priceAt(ts time.Time) float64 {
+	for _, z := range t.zones {
+		if z.Matches(ts) {
+			return z.Price
+		}
+	}
+
+	return t.Price
 }
 
 func (t *Fixed) CurrentPrice() (float64, error) {
-	return t.Price, nil
+	return t.priceAt(time.Now()), nil
 }
 
 func (t *Fixed) IsCheap() (bool, error) {
-	return false, nil
+	if t.Cheap == 0 {
+		return false, nil
+	}
+
+	price, err := t.CurrentPrice()
+	return price <= t.Cheap, err
+}
+
+// Rates implements the api.TariffForecast interface
+func (t *Fixed) Rates() (api.Rates, error) {
+	// align slots on local hours, truncating would use UTC hours
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+
+	res := make(api.Rates, 0, int(forecastHorizon/time.Hour))
+	for slot := start; slot.Before(start.Add(forecastHorizon)); slot = slot.Add(time.Hour) {
+		res = append(res, api.Rate{
+			Start: slot,
+			End:   slot.Add(time.Hour),
+			Price: t.priceAt(slot),
+		})
+	}
+
+	return res, nil
 }
@@ -0,0 +1,87 @@
+package fixed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Zone is a time-of-use tariff zone
+type Zone struct {
+	Days  []time.Weekday // empty means all days
+	Hours HourRange
+	Price float64
+}
+
+// Matches checks if the zone applies to the given time.
+// Hours after midnight of a wrapping range belong to the previous day.
+func (z Zone) Matches(t time.Time) bool {
+	if !z.Hours.Contains(t.Hour()) {
+		return false
+	}
+
+	if len(z.Days) == 0 {
+		return true
+	}
+
+	day := t.Weekday()
+	if z.Hours.From > z.Hours.To && t.Hour() < z.Hours.To {
+		day = (day + 6) % 7
+	}
+
+	for _, d := range z.Days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HourRange is a range of hours [From, To). Ranges with From > To wrap around midnight.
+type HourRange struct {
+	From, To int
+}
+
+// Contains checks if hour is within the range
+func (r HourRange) Contains(hour int) bool {
+	if r.From == r.To {
+		return true
+	}
+
+	if r.From < r.To {
+		return hour >= r.From && hour < r.To
+	}
+
+	return hour >= r.From || hour < r.To
+}
+
+// ParseHours parses an hour range like `22-6`. Empty string means all day.
+func ParseHours(s string) (HourRange, error) {
+	var res HourRange
+
+	if strings.TrimSpace(s) == "" {
+		return res, nil
+	}
+
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) != 2 {
+		return res, fmt.Errorf("invalid hours: %s", s)
+	}
+
+	for i, b := range bounds {
+		hour, err := strconv.Atoi(strings.TrimSpace(b))
+		if err != nil || hour < 0 || hour > 24 {
+			return res, fmt.Errorf("invalid hours: %s", s)
+		}
+
+		if i == 0 {
+			res.From = hour % 24
+		} else {
+			res.To = hour % 24
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,38 @@
+package fixed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evcc-io/evcc/util"
+)
+
+func TestZoneMatches(t *testing.T) {
+	days, _ := util.ParseDays("Mon-Fri")
+	hours, err := ParseHours("22-6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z := Zone{Days: days, Hours: hours, Price: 0.2}
+
+	tc := []struct {
+		time  time.Time
+		match bool
+	}{
+		{time.Date(2021, 12, 6, 23, 0, 0, 0, time.Local), true},   // Mon
+		{time.Date(2021, 12, 6, 5, 59, 0, 0, time.Local), false},  // Mon, belongs to Sun night
+		{time.Date(2021, 12, 7, 5, 59, 0, 0, time.Local), true},   // Tue, belongs to Mon night
+		{time.Date(2021, 12, 6, 6, 0, 0, 0, time.Local), false},   // Mon
+		{time.Date(2021, 12, 6, 12, 0, 0, 0, time.Local), false},  // Mon
+		{time.Date(2021, 12, 5, 23, 0, 0, 0, time.Local), false},  // Sun
+		{time.Date(2021, 12, 11, 5, 0, 0, 0, time.Local), true},   // Sat, belongs to Fri night
+		{time.Date(2021, 12, 11, 23, 0, 0, 0, time.Local), false}, // Sat
+	}
+
+	for _, tc := range tc {
+		if res := z.Matches(tc.time); res != tc.match {
+			t.Errorf("%v: expected %v, got %v", tc.time, tc.match, res)
+		}
+	}
+}
@@ -25,7 +25,10 @@ type Tibber struct {
 	data   []tibber.PriceInfo
 }
 
-var _ api.Tariff = (*Tibber)(nil)
+var (
+	_ api.Tariff         = (*Tibber)(nil)
+	_ api.TariffForecast = (*Tibber)(nil)
+)
 
 func NewTibber(other map[string]interface{}) (*Tibber, error) {
 	t := &Tibber{
@@ -93,7 +96,8 @@ func (t *Tibber) Run() {
 		}
 
 		t.mux.Lock()
-		t.data = res.Viewer.Home.CurrentSubscription.PriceInfo.Today
+		pi := res.Viewer.Home.CurrentSubscription.PriceInfo
+		t.data = append(pi.Today, pi.Tomorrow...)
 		t.mux.Unlock()
 	}
 }
@@ -116,3 +120,27 @@ func (t *Tibber) IsCheap() (bool, error) {
 	price, err := t.CurrentPrice()
 	return price <= t.Cheap, err
 }
+
+// Rates implements the api.TariffForecast interface
+func (t *Tibber) Rates() (api.Rates, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	res := make(api.Rates, 0, len(t.data))
+	for _, pi := range t.data {
+		// tibber prices are hourly
+		if end := pi.StartsAt.Add(time.Hour); end.After(time.Now()) {
+			res = append(res, api.Rate{
+				Start: pi.StartsAt,
+				End:   end,
+				Price: pi.Total,
+			})
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("unable to find tibber rates")
+	}
+
+	return res, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -54,3 +55,36 @@ func decodeBool16(mask uint64) func(b []byte) float64 {
 		return 0
 	}
 }
+
+// swapWords swaps the 16 bit words of a 32 bit value
+func swapWords(b []byte) []byte {
+	return []byte{b[2], b[3], b[0], b[1]}
+}
+
+// encodeUint16 converts a value to a big endian 16 bit register
+func encodeUint16(f float64) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(int64(math.Round(f))))
+	return b
+}
+
+// encodeUint32 converts a value to big endian 32 bit registers
+func encodeUint32(f float64) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(int64(math.Round(f))))
+	return b
+}
+
+// encodeUint64 converts a value to big endian 64 bit registers
+func encodeUint64(f float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(int64(math.Round(f))))
+	return b
+}
+
+// encodeIeee754 converts a value to big endian 32 bit IEEE 754 float registers
+func encodeIeee754(f float64) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, math.Float32bits(float32(f)))
+	return b
+}
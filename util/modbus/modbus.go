@@ -265,6 +265,8 @@ func RegisterOperation(r Register) (rs485.Operation, error) {
 		op.FuncCode = modbus.FuncCodeReadInputRegisters
 	case "writesingle":
 		op.FuncCode = modbus.FuncCodeWriteSingleRegister
+	case "writemultiple":
+		op.FuncCode = modbus.FuncCodeWriteMultipleRegisters
 	default:
 		return rs485.Operation{}, fmt.Errorf("invalid register type: %s", r.Type)
 	}
@@ -308,6 +310,26 @@ func RegisterOperation(r Register) (rs485.Operation, error) {
 	return op, nil
 }
 
+// RegisterEncoding returns the encoding for writing a value to multiple registers according to the register's decode type
+func RegisterEncoding(r Register) (func(float64) []byte, error) {
+	switch strings.ToLower(r.Decode) {
+	case "float32", "ieee754":
+		return encodeIeee754, nil
+	case "float32s", "ieee754s":
+		return func(f float64) []byte { return swapWords(encodeIeee754(f)) }, nil
+	case "uint16", "int16":
+		return encodeUint16, nil
+	case "uint32", "int32":
+		return encodeUint32, nil
+	case "uint32s", "int32s":
+		return func(f float64) []byte { return swapWords(encodeUint32(f)) }, nil
+	case "uint64":
+		return encodeUint64, nil
+	default:
+		return nil, fmt.Errorf("invalid register encoding: %s", r.Decode)
+	}
+}
+
 // SunSpecOperation is a sunspec modbus operation
 type SunSpecOperation struct {
 	Model, Block int
@@ -28,3 +28,51 @@ func TestParsePoint(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterEncoding(t *testing.T) {
+	tc := []struct {
+		decode string
+		value  float64
+	}{
+		{"float32", 1.5},
+		{"float32s", -1.5},
+		{"uint16", 4200},
+		{"int16", -4200},
+		{"uint32", 70000},
+		{"uint32s", 70000},
+		{"int32", -70000},
+		{"int32s", -70000},
+		{"uint64", 1 << 40},
+	}
+
+	for _, tc := range tc {
+		t.Log(tc)
+
+		r := Register{Type: "writemultiple", Decode: tc.decode}
+
+		op, err := RegisterOperation(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encode, err := RegisterEncoding(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b := encode(tc.value)
+		if len(b) != 2*int(op.ReadLen) {
+			t.Errorf("expected %d registers, got %d bytes", op.ReadLen, len(b))
+		}
+
+		if res := op.Transform(b); res != tc.value {
+			t.Errorf("expected %v, got %v", tc.value, res)
+		}
+	}
+
+	for _, decode := range []string{"float64", "bool16"} {
+		if _, err := RegisterEncoding(Register{Decode: decode}); err == nil {
+			t.Errorf("%s: expected unsupported encoding", decode)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseWeekday parses abbreviated or full weekday names
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, d := range weekdays {
+		if s == d || s == strings.ToLower(time.Weekday(i).String()) {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday: %s", s)
+}
+
+// ParseDays parses a comma-separated list of weekdays or weekday ranges like `Mon-Fri,Sun`
+func ParseDays(s string) ([]time.Weekday, error) {
+	var res []time.Weekday
+
+	if strings.TrimSpace(s) == "" {
+		return res, nil
+	}
+
+	for _, segment := range strings.Split(s, ",") {
+		bounds := strings.SplitN(segment, "-", 2)
+
+		from, err := parseWeekday(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+
+		to := from
+		if len(bounds) == 2 {
+			if to, err = parseWeekday(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+
+		for d := from; ; d = (d + 1) % 7 {
+			res = append(res, d)
+			if d == to {
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDays(t *testing.T) {
+	tc := []struct {
+		in  string
+		out []time.Weekday
+	}{
+		{"", nil},
+		{"Mon", []time.Weekday{time.Monday}},
+		{"Mon-Fri", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{"sat,sunday", []time.Weekday{time.Saturday, time.Sunday}},
+		{"Fri-Mon", []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+	}
+
+	for _, tc := range tc {
+		res, err := ParseDays(tc.in)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(res, tc.out) {
+			t.Errorf("%s: expected %v, got %v", tc.in, tc.out, res)
+		}
+	}
+
+	for _, in := range []string{"Foo", "Monkey", "Fri-Sundays"} {
+		if _, err := ParseDays(in); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/evcc-io/evcc/api"
+	"github.com/evcc-io/evcc/core/plan"
 )
 
 const (
@@ -48,6 +49,15 @@ func NewFromConfig(typ string, other map[string]interface{}) (v api.Vehicle, err
 		if v, err = factory(other); err != nil {
 			err = fmt.Errorf("cannot create vehicle '%s': %w", typ, err)
 		}
+
+		if err == nil {
+			for _, p := range v.Plans() {
+				if err = plan.Validate(p); err != nil {
+					err = fmt.Errorf("cannot create vehicle '%s': plan: %w", typ, err)
+					break
+				}
+			}
+		}
 	} else {
 		err = fmt.Errorf("invalid vehicle type: %s", typ)
 	}
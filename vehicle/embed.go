@@ -8,6 +8,7 @@ type embed struct {
 	Phases_      int              `mapstructure:"phases"`
 	Identifiers_ []string         `mapstructure:"identifiers"`
 	OnIdentify   api.ActionConfig `mapstructure:"onIdentify"`
+	Plans_       []api.Plan       `mapstructure:"plans"`
 }
 
 // Title implements the api.Vehicle interface
@@ -34,3 +35,8 @@ func (v *embed) Identifiers() []string {
 func (v *embed) OnIdentified() api.ActionConfig {
 	return v.OnIdentify
 }
+
+// Plans implements the api.Vehicle interface
+func (v *embed) Plans() []api.Plan {
+	return v.Plans_
+}
@@ -40,6 +40,11 @@ func (v *Wrapper) OnIdentified() api.ActionConfig {
 	return api.ActionConfig{}
 }
 
+// Plans implements the api.Vehicle interface
+func (v *Wrapper) Plans() []api.Plan {
+	return nil
+}
+
 var _ api.Battery = (*Wrapper)(nil)
 
 // SoC implements the api.Battery interface